@@ -0,0 +1,133 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// AuthSecp256k1SchemaHash is the schema of auth claim with secp256k1
+// (Ethereum) public key.
+// Keccak256(https://schema.iden3.io/core/jsonld/auth.jsonld#AuthSecp256k1Credential) last 16 bytes
+// Hex: 3919fff765efc742643cb067844631fc
+var AuthSecp256k1SchemaHash = SchemaHash{57, 25, 255, 247, 101, 239, 199, 66, 100, 60, 176, 103, 132, 70, 49, 252}
+
+// secp256k1 compressed public key (33 bytes) does not fit one slot, it is
+// split into the first 17 bytes stored in index slot A and the last 16
+// bytes stored in index slot B. Both parts are big-endian integers.
+const authSecp256k1SlotALn = 17
+
+var (
+	// ErrNotAuthSecp256k1Claim returns when the claim is not an auth claim
+	// with secp256k1 key.
+	ErrNotAuthSecp256k1Claim = errors.New("claim is not secp256k1 auth claim")
+	// ErrInvalidSignature returns when the signature verification fails
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrInvalidSecp256k1PubKey returns when bytes are not a compressed
+	// secp256k1 public key or the point is not on the curve.
+	ErrInvalidSecp256k1PubKey = errors.New("invalid secp256k1 public key")
+)
+
+// NewAuthSecp256k1Claim creates auth claim of secp256k1 public key. The key
+// must be 33 bytes compressed SEC 1 key.
+func NewAuthSecp256k1Claim(compressedPubKey []byte,
+	revNonce uint64) (*Claim, error) {
+
+	_, err := parseSecp256k1PubKey(compressedPubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	slotA := new(big.Int).SetBytes(compressedPubKey[:authSecp256k1SlotALn])
+	slotB := new(big.Int).SetBytes(compressedPubKey[authSecp256k1SlotALn:])
+	return NewClaim(AuthSecp256k1SchemaHash,
+		WithIndexDataInts(slotA, slotB),
+		WithRevocationNonce(revNonce))
+}
+
+// Secp256k1PubKeyFromAuthClaim returns 33 bytes compressed secp256k1 public
+// key of the auth claim.
+func Secp256k1PubKeyFromAuthClaim(c *Claim) ([]byte, error) {
+	if c.GetSchemaHash() != AuthSecp256k1SchemaHash {
+		return nil, ErrNotAuthSecp256k1Claim
+	}
+
+	slotA := c.index[2].ToInt()
+	slotB := c.index[3].ToInt()
+	if slotA.BitLen() > 8*authSecp256k1SlotALn ||
+		slotB.BitLen() > 8*(secp256k1.PubKeyBytesLenCompressed-authSecp256k1SlotALn) {
+
+		return nil, fmt.Errorf("%w: invalid key slots",
+			ErrNotAuthSecp256k1Claim)
+	}
+
+	pubKey := make([]byte, secp256k1.PubKeyBytesLenCompressed)
+	slotA.FillBytes(pubKey[:authSecp256k1SlotALn])
+	slotB.FillBytes(pubKey[authSecp256k1SlotALn:])
+
+	_, err := parseSecp256k1PubKey(pubKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotAuthSecp256k1Claim, err)
+	}
+	return pubKey, nil
+}
+
+// VerifyAuthSecp256k1Signature verifies ECDSA signature of the 32 bytes
+// digest (e.g. Keccak256 hash of the message) by the key of the auth claim.
+// The signature is 64 bytes `r || s` or 65 bytes Ethereum signature
+// `r || s || v`, v is ignored. r and s must be in [1, N-1] and s must be in
+// the lower half of the curve order (EIP-2), malleable high-S signatures
+// are rejected. Returns ErrInvalidSignature if the verification fails.
+func VerifyAuthSecp256k1Signature(c *Claim, digest, sig []byte) error {
+	pubKeyBytes, err := Secp256k1PubKeyFromAuthClaim(c)
+	if err != nil {
+		return err
+	}
+	pubKey, err := parseSecp256k1PubKey(pubKeyBytes)
+	if err != nil {
+		return err
+	}
+
+	if len(digest) != 32 {
+		return fmt.Errorf("%w: digest must be 32 bytes long",
+			ErrInvalidSignature)
+	}
+	if len(sig) != 64 && len(sig) != 65 {
+		return fmt.Errorf("%w: signature must be 64 or 65 bytes long",
+			ErrInvalidSignature)
+	}
+
+	var r, s secp256k1.ModNScalar
+	if r.SetByteSlice(sig[:32]) || r.IsZero() {
+		return fmt.Errorf("%w: r is out of range", ErrInvalidSignature)
+	}
+	if s.SetByteSlice(sig[32:64]) || s.IsZero() {
+		return fmt.Errorf("%w: s is out of range", ErrInvalidSignature)
+	}
+	if s.IsOverHalfOrder() {
+		return fmt.Errorf("%w: s is in the upper half of the curve order",
+			ErrInvalidSignature)
+	}
+
+	if !ecdsa.NewSignature(&r, &s).Verify(digest, pubKey) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// parseSecp256k1PubKey parses 33 bytes compressed SEC 1 public key and
+// checks that the point is on the curve
+func parseSecp256k1PubKey(b []byte) (*secp256k1.PublicKey, error) {
+	if len(b) != secp256k1.PubKeyBytesLenCompressed {
+		return nil, fmt.Errorf("%w: key must be %v bytes long",
+			ErrInvalidSecp256k1PubKey, secp256k1.PubKeyBytesLenCompressed)
+	}
+	pubKey, err := secp256k1.ParsePubKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSecp256k1PubKey, err)
+	}
+	return pubKey, nil
+}
@@ -0,0 +1,232 @@
+package core
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/iden3/go-iden3-crypto/keccak256"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthSecp256k1SchemaHash(t *testing.T) {
+	h := keccak256.Hash([]byte(
+		"https://schema.iden3.io/core/jsonld/auth.jsonld#AuthSecp256k1Credential"))
+	var want SchemaHash
+	copy(want[:], h[len(h)-len(want):])
+	require.Equal(t, want, AuthSecp256k1SchemaHash)
+}
+
+// testSecp256k1Sign signs the digest with the private key and returns
+// Ethereum signature `r || s || v`
+func testSecp256k1Sign(k *secp256k1.PrivateKey, digest []byte) []byte {
+	// compact signature is `v || r || s`
+	compact := ecdsa.SignCompact(k, digest, false)
+	return append(compact[1:], compact[0])
+}
+
+func TestAuthSecp256k1Claim(t *testing.T) {
+	d, err := hex.DecodeString(
+		"4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	require.NoError(t, err)
+	k := secp256k1.PrivKeyFromBytes(d)
+	pubKey := k.PubKey().SerializeCompressed()
+
+	claim, err := NewAuthSecp256k1Claim(pubKey, 42)
+	require.NoError(t, err)
+	require.Equal(t, AuthSecp256k1SchemaHash, claim.GetSchemaHash())
+	require.Equal(t, uint64(42), claim.GetRevocationNonce())
+
+	pubKey2, err := Secp256k1PubKeyFromAuthClaim(claim)
+	require.NoError(t, err)
+	require.Equal(t, pubKey, pubKey2)
+
+	// the claim survives serialization
+	claimHex, err := claim.Hex()
+	require.NoError(t, err)
+	var claim2 Claim
+	require.NoError(t, claim2.FromHex(claimHex))
+	pubKey3, err := Secp256k1PubKeyFromAuthClaim(&claim2)
+	require.NoError(t, err)
+	require.Equal(t, pubKey, pubKey3)
+
+	digest := keccak256.Hash([]byte("message"))
+	sig := testSecp256k1Sign(k, digest)
+	require.NoError(t, VerifyAuthSecp256k1Signature(claim, digest, sig))
+	require.NoError(t, VerifyAuthSecp256k1Signature(claim, digest, sig[:64]))
+
+	otherDigest := keccak256.Hash([]byte("other message"))
+	err = VerifyAuthSecp256k1Signature(claim, otherDigest, sig)
+	require.True(t, errors.Is(err, ErrInvalidSignature), err)
+	err = VerifyAuthSecp256k1Signature(claim, digest[:31], sig)
+	require.True(t, errors.Is(err, ErrInvalidSignature), err)
+	err = VerifyAuthSecp256k1Signature(claim, digest, sig[:63])
+	require.True(t, errors.Is(err, ErrInvalidSignature), err)
+}
+
+func TestAuthSecp256k1Claim_Errors(t *testing.T) {
+	invalidKey, err := hex.DecodeString(
+		"020000000000000000000000000000000000000000000000000000000000000005")
+	require.NoError(t, err)
+	_, err = NewAuthSecp256k1Claim(invalidKey, 0)
+	require.ErrorIs(t, err, ErrInvalidSecp256k1PubKey)
+
+	bjjClaim, err := NewClaim(AuthSchemaHash,
+		WithIndexDataInts(big.NewInt(1), big.NewInt(2)))
+	require.NoError(t, err)
+	_, err = Secp256k1PubKeyFromAuthClaim(bjjClaim)
+	require.ErrorIs(t, err, ErrNotAuthSecp256k1Claim)
+
+	// slots are too large
+	claim, err := NewClaim(AuthSecp256k1SchemaHash,
+		WithIndexDataInts(new(big.Int).Lsh(big.NewInt(1), 140),
+			big.NewInt(2)))
+	require.NoError(t, err)
+	_, err = Secp256k1PubKeyFromAuthClaim(claim)
+	require.ErrorIs(t, err, ErrNotAuthSecp256k1Claim)
+	err = VerifyAuthSecp256k1Signature(claim, make([]byte, 32),
+		make([]byte, 64))
+	require.ErrorIs(t, err, ErrNotAuthSecp256k1Claim)
+}
+
+// secp256k1N is the order of secp256k1 base point
+var secp256k1N, _ = new(big.Int).SetString(
+	"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+
+func TestVerifyAuthSecp256k1Signature_KnownAnswers(t *testing.T) {
+	testCases := []struct {
+		name    string
+		address string
+		pubKey  string
+		digest  string
+		sig     string
+	}{
+		{
+			// web3.eth.accounts.sign("Some data", privateKey) from web3.js
+			// documentation, private key
+			// 0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318
+			name:    "web3.js personal message",
+			address: "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23",
+			pubKey:  "024e3b81af9c2234cad09d679ce6035ed1392347ce64ce405f5dcd36228a25de6e",
+			digest:  "1da44b586eb0729ff70a73c326926f6ed5a25f5b056e7f47fbc6e58d86871655",
+			sig: "b91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd" +
+				"6007e74cd82e037b800186422fc2da167c747ef045e5d18a5f5d4300f8e1a029" +
+				"1c",
+		},
+		{
+			// transaction example from EIP-155, private key 0x4646...46
+			name:    "EIP-155 transaction",
+			address: "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F",
+			pubKey:  "024bc2a31265153f07e70e0bab08724e6b85e217f8cd628ceb62974247bb493382",
+			digest:  "daf5a779ae972f972197303d7b574746c7ef83eadac0f2791ad23db92e4c8e53",
+			sig: "28ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276" +
+				"67cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83" +
+				"25",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pubKeyBytes, err := hex.DecodeString(tc.pubKey)
+			require.NoError(t, err)
+			digest, err := hex.DecodeString(tc.digest)
+			require.NoError(t, err)
+			sig, err := hex.DecodeString(tc.sig)
+			require.NoError(t, err)
+
+			// the key is the key of the published address
+			pubKey, err := secp256k1.ParsePubKey(pubKeyBytes)
+			require.NoError(t, err)
+			h := keccak256.Hash(pubKey.SerializeUncompressed()[1:])
+			var addr [20]byte
+			copy(addr[:], h[12:])
+			require.Equal(t, tc.address, EthAddressToString(addr))
+
+			claim, err := NewAuthSecp256k1Claim(pubKeyBytes, 0)
+			require.NoError(t, err)
+			require.NoError(t, VerifyAuthSecp256k1Signature(claim, digest, sig))
+		})
+	}
+}
+
+func TestVerifyAuthSecp256k1Signature_Invalid(t *testing.T) {
+	pubKey, err := hex.DecodeString(
+		"024e3b81af9c2234cad09d679ce6035ed1392347ce64ce405f5dcd36228a25de6e")
+	require.NoError(t, err)
+	claim, err := NewAuthSecp256k1Claim(pubKey, 0)
+	require.NoError(t, err)
+	digest, err := hex.DecodeString(
+		"1da44b586eb0729ff70a73c326926f6ed5a25f5b056e7f47fbc6e58d86871655")
+	require.NoError(t, err)
+	r, ok := new(big.Int).SetString(
+		"b91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd", 16)
+	require.True(t, ok)
+	s, ok := new(big.Int).SetString(
+		"6007e74cd82e037b800186422fc2da167c747ef045e5d18a5f5d4300f8e1a029", 16)
+	require.True(t, ok)
+
+	sigOf := func(r, s *big.Int) []byte {
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig
+	}
+	require.NoError(t, VerifyAuthSecp256k1Signature(claim, digest,
+		sigOf(r, s)))
+
+	maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256),
+		big.NewInt(1))
+	otherDigest := append([]byte(nil), digest...)
+	otherDigest[31] ^= 1
+
+	testCases := []struct {
+		name   string
+		digest []byte
+		sig    []byte
+	}{
+		{"high s", digest, sigOf(r, new(big.Int).Sub(secp256k1N, s))},
+		{"r is zero", digest, sigOf(big.NewInt(0), s)},
+		{"s is zero", digest, sigOf(r, big.NewInt(0))},
+		{"r is N", digest, sigOf(secp256k1N, s)},
+		{"s is N", digest, sigOf(r, secp256k1N)},
+		{"r is max uint256", digest, sigOf(maxUint256, s)},
+		{"s is max uint256", digest, sigOf(r, maxUint256)},
+		{"r and s are swapped", digest, sigOf(s, r)},
+		{"r is one", digest, sigOf(big.NewInt(1), s)},
+		{"s is one", digest, sigOf(r, big.NewInt(1))},
+		{"modified digest", otherDigest, sigOf(r, s)},
+		{"short digest", digest[:31], sigOf(r, s)},
+		{"short signature", digest, sigOf(r, s)[:63]},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifyAuthSecp256k1Signature(claim, tc.digest, tc.sig)
+			require.True(t, errors.Is(err, ErrInvalidSignature), err)
+		})
+	}
+}
+
+func TestNewAuthSecp256k1Claim_InvalidKeys(t *testing.T) {
+	keys := []string{
+		// x³ + 7 is not a square
+		"020000000000000000000000000000000000000000000000000000000000000005",
+		// x is P
+		"02fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f",
+		// invalid prefix
+		"054e3b81af9c2234cad09d679ce6035ed1392347ce64ce405f5dcd36228a25de6e",
+		// uncompressed key
+		"044e3b81af9c2234cad09d679ce6035ed1392347ce64ce405f5dcd36228a25de6e" +
+			"2d5f9f7a5f0d4e6d35ac0e06f0d4e8c4e33b5d1f8a6b6f1a1c7d8d3b6f2c1a9e8d",
+		// short key
+		"024e3b81af9c2234cad09d679ce6035ed1392347ce64ce405f5dcd36228a25de",
+	}
+	for _, k := range keys {
+		b, err := hex.DecodeString(k)
+		require.NoError(t, err)
+		_, err = NewAuthSecp256k1Claim(b, 0)
+		require.ErrorIs(t, err, ErrInvalidSecp256k1PubKey, k)
+	}
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+)
+
+// ChainID is an EIP-155 identifier of the blockchain network
+type ChainID int32
+
+// chainIDs is a map of blockchain networks to their EIP-155 chain IDs
+var chainIDs = map[DIDNetworkFlag]ChainID{
+	{Blockchain: Ethereum, NetworkID: Main}:    1,
+	{Blockchain: Ethereum, NetworkID: Goerli}:  5,
+	{Blockchain: Ethereum, NetworkID: Sepolia}: 11155111,
+
+	{Blockchain: Polygon, NetworkID: Main}:   137,
+	{Blockchain: Polygon, NetworkID: Mumbai}: 80001,
+
+	{Blockchain: ZkEVM, NetworkID: Main}: 1101,
+	{Blockchain: ZkEVM, NetworkID: Test}: 1442,
+}
+
+// GetChainID returns chain ID of the blockchain network
+func GetChainID(blockchain Blockchain, network NetworkID) (ChainID, error) {
+	chainID, ok := chainIDs[DIDNetworkFlag{Blockchain: blockchain,
+		NetworkID: network}]
+	if !ok {
+		return 0, fmt.Errorf("%w: no chain ID for %v:%v",
+			ErrNetworkNotSupportedForDID, blockchain, network)
+	}
+	return chainID, nil
+}
+
+// NetworkByChainID returns blockchain and network by chain ID
+func NetworkByChainID(chainID ChainID) (Blockchain, NetworkID, error) {
+	for k, v := range chainIDs {
+		if v == chainID {
+			return k.Blockchain, k.NetworkID, nil
+		}
+	}
+	return UnknownChain, UnknownNetwork, fmt.Errorf(
+		"%w: unknown chain ID %v", ErrNetworkNotSupportedForDID, chainID)
+}
+
+// ChainIDFromID returns chain ID of the blockchain network encoded in ID
+func ChainIDFromID(id ID) (ChainID, error) {
+	blockchain, err := BlockchainFromID(id)
+	if err != nil {
+		return 0, err
+	}
+	networkID, err := NetworkIDFromID(id)
+	if err != nil {
+		return 0, err
+	}
+	return GetChainID(blockchain, networkID)
+}
+
+// ChainIDFromDID returns chain ID of the blockchain network of the DID
+func ChainIDFromDID(did w3c.DID) (ChainID, error) {
+	id, err := IDFromDID(did)
+	if err != nil {
+		return 0, err
+	}
+	return ChainIDFromID(id)
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainIDFromDID(t *testing.T) {
+	did, err := w3c.ParseDID(
+		"did:iden3:polygon:mumbai:wyFiV4w71QgWPn6bYLsZoysFay66gKtVa9kfu6yMZ")
+	require.NoError(t, err)
+	chainID, err := ChainIDFromDID(*did)
+	require.NoError(t, err)
+	require.Equal(t, ChainID(80001), chainID)
+
+	blockchain, networkID, err := NetworkByChainID(chainID)
+	require.NoError(t, err)
+	require.Equal(t, Polygon, blockchain)
+	require.Equal(t, Mumbai, networkID)
+
+	did, err = w3c.ParseDID(
+		"did:iden3:readonly:tN4jDinQUdMuJJo6GbVeKPNTPCJ7txyXTWU4T2tJa")
+	require.NoError(t, err)
+	_, err = ChainIDFromDID(*did)
+	require.ErrorIs(t, err, ErrNetworkNotSupportedForDID)
+
+	_, _, err = NetworkByChainID(42)
+	require.ErrorIs(t, err, ErrNetworkNotSupportedForDID)
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"bytes"
+	"database/sql/driver"
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
@@ -114,6 +115,33 @@ func (sh SchemaHash) MarshalText() ([]byte, error) {
 	return dst, nil
 }
 
+// Value implements driver.Valuer interface. SchemaHash is stored as HEX
+// string.
+func (sh SchemaHash) Value() (driver.Value, error) {
+	return hex.EncodeToString(sh[:]), nil
+}
+
+// Scan implements sql.Scanner interface. SchemaHash is scanned from HEX
+// string.
+func (sh *SchemaHash) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("can't scan SchemaHash from %T", src)
+	}
+
+	newSH, err := NewSchemaHashFromHex(s)
+	if err != nil {
+		return err
+	}
+	*sh = newSH
+	return nil
+}
+
 // NewSchemaHashFromHex creates new SchemaHash from hex string
 func NewSchemaHashFromHex(s string) (SchemaHash, error) {
 	var sh SchemaHash
@@ -846,6 +874,34 @@ func (c *Claim) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
+// Value implements driver.Valuer interface. Claim is stored in binary form,
+// see MarshalBinary.
+func (c Claim) Value() (driver.Value, error) {
+	return c.MarshalBinary()
+}
+
+// Scan implements sql.Scanner interface. Claim is scanned from binary form,
+// see UnmarshalBinary.
+func (c *Claim) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("can't scan Claim from %T", src)
+	}
+
+	var newClaim Claim
+	err := newClaim.UnmarshalBinary(data)
+	if err != nil {
+		return err
+	}
+	*c = newClaim
+	return nil
+}
+
 func (c *Claim) FromHex(hexStr string) error {
 
 	data, err := hex.DecodeString(hexStr)
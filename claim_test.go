@@ -377,6 +377,20 @@ func TestClaimBinarySerialization(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, binData, result)
 	})
+
+	t.Run("sql", func(t *testing.T) {
+		v, err := want.Value()
+		require.NoError(t, err)
+		require.Equal(t, binData, v)
+
+		var result Claim
+		require.NoError(t, result.Scan(v))
+		require.Equal(t, want, result)
+
+		require.EqualError(t, result.Scan(1), "can't scan Claim from int")
+		require.Error(t, result.Scan(binData[1:]))
+		require.Equal(t, want, result)
+	})
 }
 
 func TestNewSchemaHashFromHex(t *testing.T) {
@@ -391,6 +405,28 @@ func TestNewSchemaHashFromHex(t *testing.T) {
 	require.Equal(t, exp[:], got[:])
 }
 
+func TestSchemaHash_SQL(t *testing.T) {
+	hash := "ca938857241db9451ea329256b9c06e5"
+	sh, err := NewSchemaHashFromHex(hash)
+	require.NoError(t, err)
+
+	v, err := sh.Value()
+	require.NoError(t, err)
+	require.Equal(t, hash, v)
+
+	var sh2 SchemaHash
+	require.NoError(t, sh2.Scan(v))
+	require.Equal(t, sh, sh2)
+
+	var sh3 SchemaHash
+	require.NoError(t, sh3.Scan([]byte(hash)))
+	require.Equal(t, sh, sh3)
+
+	require.EqualError(t, sh3.Scan(nil), "can't scan SchemaHash from <nil>")
+	require.Error(t, sh3.Scan("ca93"))
+	require.Equal(t, sh, sh3)
+}
+
 func TestSchemaHash_BigInt(t *testing.T) {
 	schema, err := NewSchemaHashFromHex("ca938857241db9451ea329256b9c06e5")
 	require.NoError(t, err)
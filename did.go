@@ -219,6 +219,10 @@ func newIDFromUnsupportedDID(did w3c.DID) ID {
 
 func idFromDID(did w3c.DID) (ID, error) {
 	method := DIDMethod(did.Method)
+	if method == DIDMethodPKH || method == DIDMethodEthr {
+		return idFromEthDID(did)
+	}
+
 	_, ok := DIDMethodByte[method]
 	if !ok || method == DIDMethodOther {
 		return ID{}, ErrMethodUnknown
@@ -0,0 +1,458 @@
+package core
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+)
+
+// Iden3StateInfo2023Type is a type of verification method that holds the
+// identity state information.
+const Iden3StateInfo2023Type = "Iden3StateInfo2023"
+
+// Iden3AuthContext is a JSON-LD context that defines Iden3StateInfo2023
+// verification method.
+const Iden3AuthContext = "https://schema.iden3.io/core/jsonld/auth.jsonld"
+
+// StateInfoFragment is a fragment of Iden3StateInfo2023 verification method
+// ID in the DID document.
+const StateInfoFragment = "state-info"
+
+// DIDParamState is a name of iden3 DID URL parameter that references the
+// identity state. The state is encoded as hex of little-endian bytes.
+const DIDParamState = "state"
+
+var (
+	// ErrStateNotFound returns by StateSource when identity has no published
+	// state.
+	ErrStateNotFound = errors.New("identity state not found")
+	// ErrGenesisStateMismatch returns when identity has no published state
+	// and the given state is not its genesis state.
+	ErrGenesisStateMismatch = errors.New("state is not genesis state of identity")
+	// ErrGenesisStateRequired returns when identity has no published state
+	// and its genesis state is not given, so the DID document can't be
+	// built.
+	ErrGenesisStateRequired = errors.New(
+		"genesis state is required for identity without published state")
+)
+
+// StateInfo is an information about identity state published in the state
+// contract.
+type StateInfo struct {
+	State               *big.Int
+	ReplacedByState     *big.Int
+	CreatedAtTimestamp  uint64
+	ReplacedAtTimestamp uint64
+	CreatedAtBlock      uint64
+	ReplacedAtBlock     uint64
+}
+
+// GistRootInfo is an information about the root of Global Identity State
+// Tree published in the state contract.
+type GistRootInfo struct {
+	Root                *big.Int
+	ReplacedByRoot      *big.Int
+	CreatedAtTimestamp  uint64
+	ReplacedAtTimestamp uint64
+	CreatedAtBlock      uint64
+	ReplacedAtBlock     uint64
+}
+
+// StateSource provides identity states published in the state contracts.
+type StateSource interface {
+	// StateContractAddress returns the address of the state contract on the
+	// chain.
+	StateContractAddress(ctx context.Context, chainID ChainID) (string, error)
+	// StateInfo returns the latest published state of identity. Returns
+	// ErrStateNotFound (or nil info) if identity has no published state.
+	StateInfo(ctx context.Context, chainID ChainID, id ID) (*StateInfo, error)
+	// GistRootInfo returns the latest root of Global Identity State Tree.
+	GistRootInfo(ctx context.Context, chainID ChainID) (*GistRootInfo, error)
+}
+
+// StateFromDIDURL returns the identity state referenced by `state` DID URL
+// parameter. Returns nil if the parameter is not set.
+func StateFromDIDURL(did w3c.DID) (*big.Int, error) {
+	stateHex := did.QueryParam(DIDParamState)
+	if stateHex == "" {
+		return nil, nil
+	}
+	state, err := intFromHex(stateHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state parameter: %w", err)
+	}
+	return state, nil
+}
+
+// SetDIDURLState sets `state` DID URL parameter to the identity state
+func SetDIDURLState(did *w3c.DID, state *big.Int) error {
+	stateHex, err := hexFromInt(state)
+	if err != nil {
+		return err
+	}
+	did.SetQueryParam(DIDParamState, stateHex)
+	return nil
+}
+
+// Iden3StateInfo2023 is a verification method that holds the state of
+// identity and the root of Global Identity State Tree.
+type Iden3StateInfo2023 struct {
+	// StateContractAddress is the state contract address prefixed with
+	// the chain ID: `<chainID>:<address>`. Empty for readonly identities.
+	StateContractAddress string `json:"stateContractAddress,omitempty"`
+	// Published is true if the identity state is published in the state
+	// contract.
+	Published bool `json:"published"`
+	// Genesis is true if the identity state from Info is the genesis state
+	// of identity.
+	Genesis bool          `json:"genesis"`
+	Info    *StateInfo    `json:"info,omitempty"`
+	Global  *GistRootInfo `json:"global,omitempty"`
+}
+
+// Iden3StateInfo2023FromVerificationMethod extracts Iden3StateInfo2023 from
+// verification method properties.
+func Iden3StateInfo2023FromVerificationMethod(
+	vm w3c.VerificationMethod) (*Iden3StateInfo2023, error) {
+
+	if vm.Type != Iden3StateInfo2023Type {
+		return nil, fmt.Errorf("unexpected verification method type: %v",
+			vm.Type)
+	}
+
+	propsBytes, err := json.Marshal(vm.Properties)
+	if err != nil {
+		return nil, err
+	}
+
+	var si Iden3StateInfo2023
+	err = json.Unmarshal(propsBytes, &si)
+	if err != nil {
+		return nil, err
+	}
+	return &si, nil
+}
+
+func (si Iden3StateInfo2023) properties() map[string]interface{} {
+	props := map[string]interface{}{
+		"published": si.Published,
+		"genesis":   si.Genesis,
+	}
+	if si.StateContractAddress != "" {
+		props["stateContractAddress"] = si.StateContractAddress
+	}
+	if si.Info != nil {
+		props["info"] = si.Info
+	}
+	if si.Global != nil {
+		props["global"] = si.Global
+	}
+	return props
+}
+
+// Iden3DIDDocumentBuilder builds DID documents for iden3 and polygonid
+// DIDs with the Iden3StateInfo2023 verification method.
+type Iden3DIDDocumentBuilder struct {
+	source            StateSource
+	genesisFromDIDURL bool
+}
+
+// Iden3DIDDocumentBuilderOption is an option of NewIden3DIDDocumentBuilder
+type Iden3DIDDocumentBuilderOption func(*Iden3DIDDocumentBuilder)
+
+// WithGenesisStateFromDIDURL makes Build take the genesis state from `state`
+// DID URL parameter when genesisState is nil.
+func WithGenesisStateFromDIDURL() Iden3DIDDocumentBuilderOption {
+	return func(b *Iden3DIDDocumentBuilder) {
+		b.genesisFromDIDURL = true
+	}
+}
+
+// NewIden3DIDDocumentBuilder creates new Iden3DIDDocumentBuilder that gets
+// identity states from source.
+func NewIden3DIDDocumentBuilder(source StateSource,
+	opts ...Iden3DIDDocumentBuilderOption) *Iden3DIDDocumentBuilder {
+
+	b := &Iden3DIDDocumentBuilder{source: source}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Build returns DID document for did. If the identity has no published
+// state, genesisState is checked to be the genesis state of the identity
+// and is put into the document. Returns ErrGenesisStateRequired if
+// genesisState is nil and ErrGenesisStateMismatch if the check fails. With
+// WithGenesisStateFromDIDURL option the state from `state` DID URL
+// parameter is used if genesisState is nil.
+func (b *Iden3DIDDocumentBuilder) Build(ctx context.Context, did w3c.DID,
+	genesisState *big.Int) (*w3c.DIDDocument, error) {
+
+	var err error
+	if genesisState == nil && b.genesisFromDIDURL {
+		genesisState, err = StateFromDIDURL(did)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	id, err := IDFromDID(did)
+	if err != nil {
+		return nil, err
+	}
+	method, err := MethodFromID(id)
+	if err != nil {
+		return nil, err
+	}
+	if method != DIDMethodIden3 && method != DIDMethodPolygonID {
+		return nil, fmt.Errorf("%w: %v", ErrDIDMethodNotSupported, method)
+	}
+
+	var si Iden3StateInfo2023
+
+	blockchain, err := BlockchainFromID(id)
+	if err != nil {
+		return nil, err
+	}
+	if blockchain != ReadOnly {
+		err = b.fillPublishedInfo(ctx, id, &si)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !si.Published {
+		if genesisState == nil {
+			return nil, ErrGenesisStateRequired
+		}
+		isGenesis, err := CheckGenesisStateID(id.BigInt(), genesisState)
+		if err != nil {
+			return nil, err
+		}
+		if !isGenesis {
+			return nil, ErrGenesisStateMismatch
+		}
+		si.Genesis = true
+		si.Info = &StateInfo{State: new(big.Int).Set(genesisState)}
+	}
+
+	didBase := w3c.DID{Method: did.Method, ID: did.ID,
+		IDStrings: did.IDStrings}
+	vmID := didBase
+	vmID.Fragment = StateInfoFragment
+
+	return &w3c.DIDDocument{
+		Context: []interface{}{w3c.DIDContextV1, Iden3AuthContext},
+		ID:      didBase,
+		VerificationMethod: []w3c.VerificationMethod{
+			{
+				ID:         vmID,
+				Type:       Iden3StateInfo2023Type,
+				Controller: didBase,
+				Properties: si.properties(),
+			},
+		},
+	}, nil
+}
+
+func (b *Iden3DIDDocumentBuilder) fillPublishedInfo(ctx context.Context,
+	id ID, si *Iden3StateInfo2023) error {
+
+	chainID, err := ChainIDFromID(id)
+	if err != nil {
+		return err
+	}
+
+	contractAddress, err := b.source.StateContractAddress(ctx, chainID)
+	if err != nil {
+		return err
+	}
+	si.StateContractAddress = fmt.Sprintf("%d:%s", chainID, contractAddress)
+
+	si.Global, err = b.source.GistRootInfo(ctx, chainID)
+	if err != nil {
+		return err
+	}
+
+	si.Info, err = b.source.StateInfo(ctx, chainID, id)
+	if errors.Is(err, ErrStateNotFound) || (err == nil && si.Info == nil) {
+		si.Info = nil
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if si.Info.State == nil {
+		return errors.New("state source returned state info without state")
+	}
+
+	si.Published = true
+	si.Genesis, err = CheckGenesisStateID(id.BigInt(), si.Info.State)
+	return err
+}
+
+type stateInfoJSON struct {
+	State               string `json:"state"`
+	ReplacedByState     string `json:"replacedByState"`
+	CreatedAtTimestamp  string `json:"createdAtTimestamp"`
+	ReplacedAtTimestamp string `json:"replacedAtTimestamp"`
+	CreatedAtBlock      string `json:"createdAtBlock"`
+	ReplacedAtBlock     string `json:"replacedAtBlock"`
+}
+
+// MarshalJSON implements json.Marshaler interface. States are encoded as
+// hex of little-endian bytes, numbers as decimal strings.
+func (si StateInfo) MarshalJSON() ([]byte, error) {
+	var obj stateInfoJSON
+	var err error
+	obj.State, err = hexFromInt(si.State)
+	if err != nil {
+		return nil, err
+	}
+	obj.ReplacedByState, err = hexFromInt(si.ReplacedByState)
+	if err != nil {
+		return nil, err
+	}
+	obj.CreatedAtTimestamp = strconv.FormatUint(si.CreatedAtTimestamp, 10)
+	obj.ReplacedAtTimestamp = strconv.FormatUint(si.ReplacedAtTimestamp, 10)
+	obj.CreatedAtBlock = strconv.FormatUint(si.CreatedAtBlock, 10)
+	obj.ReplacedAtBlock = strconv.FormatUint(si.ReplacedAtBlock, 10)
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface
+func (si *StateInfo) UnmarshalJSON(in []byte) error {
+	var obj stateInfoJSON
+	err := json.Unmarshal(in, &obj)
+	if err != nil {
+		return err
+	}
+
+	var s StateInfo
+	s.State, err = intFromHex(obj.State)
+	if err != nil {
+		return fmt.Errorf("invalid state: %w", err)
+	}
+	s.ReplacedByState, err = intFromHex(obj.ReplacedByState)
+	if err != nil {
+		return fmt.Errorf("invalid replacedByState: %w", err)
+	}
+	err = parseUints(
+		[]string{obj.CreatedAtTimestamp, obj.ReplacedAtTimestamp,
+			obj.CreatedAtBlock, obj.ReplacedAtBlock},
+		[]*uint64{&s.CreatedAtTimestamp, &s.ReplacedAtTimestamp,
+			&s.CreatedAtBlock, &s.ReplacedAtBlock})
+	if err != nil {
+		return err
+	}
+
+	*si = s
+	return nil
+}
+
+type gistRootInfoJSON struct {
+	Root                string `json:"root"`
+	ReplacedByRoot      string `json:"replacedByRoot"`
+	CreatedAtTimestamp  string `json:"createdAtTimestamp"`
+	ReplacedAtTimestamp string `json:"replacedAtTimestamp"`
+	CreatedAtBlock      string `json:"createdAtBlock"`
+	ReplacedAtBlock     string `json:"replacedAtBlock"`
+}
+
+// MarshalJSON implements json.Marshaler interface. Roots are encoded as
+// hex of little-endian bytes, numbers as decimal strings.
+func (gi GistRootInfo) MarshalJSON() ([]byte, error) {
+	var obj gistRootInfoJSON
+	var err error
+	obj.Root, err = hexFromInt(gi.Root)
+	if err != nil {
+		return nil, err
+	}
+	obj.ReplacedByRoot, err = hexFromInt(gi.ReplacedByRoot)
+	if err != nil {
+		return nil, err
+	}
+	obj.CreatedAtTimestamp = strconv.FormatUint(gi.CreatedAtTimestamp, 10)
+	obj.ReplacedAtTimestamp = strconv.FormatUint(gi.ReplacedAtTimestamp, 10)
+	obj.CreatedAtBlock = strconv.FormatUint(gi.CreatedAtBlock, 10)
+	obj.ReplacedAtBlock = strconv.FormatUint(gi.ReplacedAtBlock, 10)
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface
+func (gi *GistRootInfo) UnmarshalJSON(in []byte) error {
+	var obj gistRootInfoJSON
+	err := json.Unmarshal(in, &obj)
+	if err != nil {
+		return err
+	}
+
+	var g GistRootInfo
+	g.Root, err = intFromHex(obj.Root)
+	if err != nil {
+		return fmt.Errorf("invalid root: %w", err)
+	}
+	g.ReplacedByRoot, err = intFromHex(obj.ReplacedByRoot)
+	if err != nil {
+		return fmt.Errorf("invalid replacedByRoot: %w", err)
+	}
+	err = parseUints(
+		[]string{obj.CreatedAtTimestamp, obj.ReplacedAtTimestamp,
+			obj.CreatedAtBlock, obj.ReplacedAtBlock},
+		[]*uint64{&g.CreatedAtTimestamp, &g.ReplacedAtTimestamp,
+			&g.CreatedAtBlock, &g.ReplacedAtBlock})
+	if err != nil {
+		return err
+	}
+
+	*gi = g
+	return nil
+}
+
+// hexFromInt returns hex of little-endian representation of field
+// element. nil is encoded as zero.
+func hexFromInt(i *big.Int) (string, error) {
+	if i == nil {
+		i = big.NewInt(0)
+	}
+	el, err := NewElemBytesFromInt(i)
+	if err != nil {
+		return "", err
+	}
+	return el.Hex(), nil
+}
+
+// intFromHex parses hex of little-endian representation of field element
+func intFromHex(s string) (*big.Int, error) {
+	var el ElemBytes
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != len(el) {
+		return nil, fmt.Errorf("invalid length: %d", len(b))
+	}
+	copy(el[:], b)
+	return fieldBytesToInt(el[:])
+}
+
+func parseUints(in []string, out []*uint64) error {
+	for i := range in {
+		if in[i] == "" {
+			*out[i] = 0
+			continue
+		}
+		v, err := strconv.ParseUint(in[i], 10, 64)
+		if err != nil {
+			return err
+		}
+		*out[i] = v
+	}
+	return nil
+}
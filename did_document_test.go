@@ -0,0 +1,289 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/stretchr/testify/require"
+)
+
+type memStateSource struct {
+	states map[ID]*StateInfo
+	gist   *GistRootInfo
+}
+
+func (s *memStateSource) StateContractAddress(_ context.Context,
+	_ ChainID) (string, error) {
+
+	return "0x134B1BE34911E39A8397ec6289782989729807a4", nil
+}
+
+func (s *memStateSource) StateInfo(_ context.Context, _ ChainID,
+	id ID) (*StateInfo, error) {
+
+	si, ok := s.states[id]
+	if !ok {
+		return nil, ErrStateNotFound
+	}
+	return si, nil
+}
+
+func (s *memStateSource) GistRootInfo(_ context.Context,
+	_ ChainID) (*GistRootInfo, error) {
+
+	return s.gist, nil
+}
+
+func testIdenState(t testing.TB, clr int64) *big.Int {
+	t.Helper()
+	state, err := IdenState(big.NewInt(clr), big.NewInt(0), big.NewInt(0))
+	require.NoError(t, err)
+	return state
+}
+
+func requireJSONEqual(t testing.TB, want, got interface{}) {
+	t.Helper()
+	wantBytes, err := json.Marshal(want)
+	require.NoError(t, err)
+	gotBytes, err := json.Marshal(got)
+	require.NoError(t, err)
+	require.JSONEq(t, string(wantBytes), string(gotBytes))
+}
+
+func TestIden3DIDDocumentBuilder_Published(t *testing.T) {
+	ctx := context.Background()
+	typ, err := BuildDIDType(DIDMethodPolygonID, Polygon, Mumbai)
+	require.NoError(t, err)
+	genesisState := testIdenState(t, 1)
+	did, err := NewDIDFromIdenState(typ, genesisState)
+	require.NoError(t, err)
+	id, err := IDFromDID(*did)
+	require.NoError(t, err)
+
+	src := &memStateSource{
+		states: map[ID]*StateInfo{id: {
+			State:              testIdenState(t, 2),
+			CreatedAtTimestamp: 1680000000,
+			CreatedAtBlock:     33000000,
+		}},
+		gist: &GistRootInfo{
+			Root:               big.NewInt(300),
+			CreatedAtTimestamp: 1680000001,
+			CreatedAtBlock:     33000001,
+		},
+	}
+
+	doc, err := NewIden3DIDDocumentBuilder(src).Build(ctx, *did, nil)
+	require.NoError(t, err)
+
+	docBytes, err := json.Marshal(doc)
+	require.NoError(t, err)
+	want := `{
+  "@context": [
+    "https://www.w3.org/ns/did/v1",
+    "https://schema.iden3.io/core/jsonld/auth.jsonld"
+  ],
+  "id": "did:polygonid:polygon:mumbai:2qEw5HAQFBh6aavAufixuUXY6J9ip4MiuH3yJbAuer",
+  "verificationMethod": [
+    {
+      "id": "did:polygonid:polygon:mumbai:2qEw5HAQFBh6aavAufixuUXY6J9ip4MiuH3yJbAuer#state-info",
+      "type": "Iden3StateInfo2023",
+      "controller": "did:polygonid:polygon:mumbai:2qEw5HAQFBh6aavAufixuUXY6J9ip4MiuH3yJbAuer",
+      "stateContractAddress": "80001:0x134B1BE34911E39A8397ec6289782989729807a4",
+      "published": true,
+      "genesis": false,
+      "info": {
+        "state": "b885ead473074d644a63d3d53af3e7278289dec2806f5bb98a4bf768ad67421d",
+        "replacedByState": "0000000000000000000000000000000000000000000000000000000000000000",
+        "createdAtTimestamp": "1680000000",
+        "replacedAtTimestamp": "0",
+        "createdAtBlock": "33000000",
+        "replacedAtBlock": "0"
+      },
+      "global": {
+        "root": "2c01000000000000000000000000000000000000000000000000000000000000",
+        "replacedByRoot": "0000000000000000000000000000000000000000000000000000000000000000",
+        "createdAtTimestamp": "1680000001",
+        "replacedAtTimestamp": "0",
+        "createdAtBlock": "33000001",
+        "replacedAtBlock": "0"
+      }
+    }
+  ]
+}`
+	require.JSONEq(t, want, string(docBytes))
+
+	var doc2 w3c.DIDDocument
+	err = json.Unmarshal(docBytes, &doc2)
+	require.NoError(t, err)
+	require.Len(t, doc2.VerificationMethod, 1)
+	si, err := Iden3StateInfo2023FromVerificationMethod(
+		doc2.VerificationMethod[0])
+	require.NoError(t, err)
+	requireJSONEqual(t, &Iden3StateInfo2023{
+		StateContractAddress: "80001:0x134B1BE34911E39A8397ec6289782989729807a4",
+		Published:            true,
+		Genesis:              false,
+		Info: &StateInfo{
+			State:              testIdenState(t, 2),
+			CreatedAtTimestamp: 1680000000,
+			CreatedAtBlock:     33000000,
+		},
+		Global: &GistRootInfo{
+			Root:               big.NewInt(300),
+			CreatedAtTimestamp: 1680000001,
+			CreatedAtBlock:     33000001,
+		},
+	}, si)
+}
+
+func TestIden3DIDDocumentBuilder_Genesis(t *testing.T) {
+	ctx := context.Background()
+	typ, err := BuildDIDType(DIDMethodIden3, Polygon, Mumbai)
+	require.NoError(t, err)
+	genesisState := testIdenState(t, 1)
+	did, err := NewDIDFromIdenState(typ, genesisState)
+	require.NoError(t, err)
+
+	src := &memStateSource{
+		states: map[ID]*StateInfo{},
+		gist:   &GistRootInfo{Root: big.NewInt(300)},
+	}
+	builder := NewIden3DIDDocumentBuilder(src)
+
+	doc, err := builder.Build(ctx, *did, genesisState)
+	require.NoError(t, err)
+	require.Equal(t, *did, doc.ID)
+	si, err := Iden3StateInfo2023FromVerificationMethod(
+		doc.VerificationMethod[0])
+	require.NoError(t, err)
+	require.False(t, si.Published)
+	require.True(t, si.Genesis)
+	require.Equal(t, 0, genesisState.Cmp(si.Info.State))
+	require.Equal(t, 0, big.NewInt(300).Cmp(si.Global.Root))
+
+	// state is unknown
+	_, err = builder.Build(ctx, *did, nil)
+	require.ErrorIs(t, err, ErrGenesisStateRequired)
+
+	_, err = builder.Build(ctx, *did, testIdenState(t, 3))
+	require.ErrorIs(t, err, ErrGenesisStateMismatch)
+}
+
+func TestIden3DIDDocumentBuilder_NilStateInfo(t *testing.T) {
+	ctx := context.Background()
+	typ, err := BuildDIDType(DIDMethodIden3, Polygon, Mumbai)
+	require.NoError(t, err)
+	genesisState := testIdenState(t, 1)
+	did, err := NewDIDFromIdenState(typ, genesisState)
+	require.NoError(t, err)
+	id, err := IDFromDID(*did)
+	require.NoError(t, err)
+
+	// nil info is treated as not published state
+	src := &memStateSource{
+		states: map[ID]*StateInfo{id: nil},
+		gist:   &GistRootInfo{Root: big.NewInt(300)},
+	}
+	builder := NewIden3DIDDocumentBuilder(src)
+	doc, err := builder.Build(ctx, *did, genesisState)
+	require.NoError(t, err)
+	si, err := Iden3StateInfo2023FromVerificationMethod(
+		doc.VerificationMethod[0])
+	require.NoError(t, err)
+	require.False(t, si.Published)
+	require.True(t, si.Genesis)
+
+	_, err = builder.Build(ctx, *did, nil)
+	require.ErrorIs(t, err, ErrGenesisStateRequired)
+
+	src.states[id] = &StateInfo{}
+	_, err = builder.Build(ctx, *did, genesisState)
+	require.EqualError(t, err,
+		"state source returned state info without state")
+}
+
+func TestIden3DIDDocumentBuilder_ReadOnly(t *testing.T) {
+	ctx := context.Background()
+	typ, err := BuildDIDType(DIDMethodIden3, ReadOnly, NoNetwork)
+	require.NoError(t, err)
+	genesisState := testIdenState(t, 1)
+	did, err := NewDIDFromIdenState(typ, genesisState)
+	require.NoError(t, err)
+
+	doc, err := NewIden3DIDDocumentBuilder(&memStateSource{}).
+		Build(ctx, *did, genesisState)
+	require.NoError(t, err)
+	si, err := Iden3StateInfo2023FromVerificationMethod(
+		doc.VerificationMethod[0])
+	require.NoError(t, err)
+	requireJSONEqual(t, &Iden3StateInfo2023{
+		Genesis: true,
+		Info: &StateInfo{
+			State: genesisState,
+		},
+	}, si)
+}
+
+func TestIden3DIDDocumentBuilder_UnsupportedDID(t *testing.T) {
+	did, err := w3c.ParseDID("did:example:123")
+	require.NoError(t, err)
+	_, err = NewIden3DIDDocumentBuilder(&memStateSource{}).
+		Build(context.Background(), *did, nil)
+	require.ErrorIs(t, err, ErrMethodUnknown)
+}
+
+func TestStateFromDIDURL(t *testing.T) {
+	did, err := w3c.ParseDID(
+		"did:iden3:polygon:mumbai:wyFiV4w71QgWPn6bYLsZoysFay66gKtVa9kfu6yMZ")
+	require.NoError(t, err)
+
+	state, err := StateFromDIDURL(*did)
+	require.NoError(t, err)
+	require.Nil(t, state)
+
+	err = SetDIDURLState(did, testIdenState(t, 1))
+	require.NoError(t, err)
+	require.Equal(t,
+		"did:iden3:polygon:mumbai:wyFiV4w71QgWPn6bYLsZoysFay66gKtVa9kfu6yMZ?state=1583a926f7364fa62182176b70c3ee8e3f4fd700cecd7cda810e037ae33a1424",
+		did.String())
+
+	did2, err := w3c.ParseDID(did.String())
+	require.NoError(t, err)
+	state, err = StateFromDIDURL(*did2)
+	require.NoError(t, err)
+	require.Equal(t, testIdenState(t, 1), state)
+
+	did2.SetQueryParam(DIDParamState, "123")
+	_, err = StateFromDIDURL(*did2)
+	require.EqualError(t, err,
+		"invalid state parameter: encoding/hex: odd length hex string")
+}
+
+func TestIden3DIDDocumentBuilder_GenesisFromDIDURL(t *testing.T) {
+	typ, err := BuildDIDType(DIDMethodIden3, Polygon, Mumbai)
+	require.NoError(t, err)
+	genesisState := testIdenState(t, 1)
+	did, err := NewDIDFromIdenState(typ, genesisState)
+	require.NoError(t, err)
+	err = SetDIDURLState(did, genesisState)
+	require.NoError(t, err)
+
+	src := &memStateSource{gist: &GistRootInfo{Root: big.NewInt(300)}}
+	_, err = NewIden3DIDDocumentBuilder(src).
+		Build(context.Background(), *did, nil)
+	require.ErrorIs(t, err, ErrGenesisStateRequired)
+
+	doc, err := NewIden3DIDDocumentBuilder(src, WithGenesisStateFromDIDURL()).
+		Build(context.Background(), *did, nil)
+	require.NoError(t, err)
+	require.Equal(t, "", doc.ID.Query)
+	si, err := Iden3StateInfo2023FromVerificationMethod(
+		doc.VerificationMethod[0])
+	require.NoError(t, err)
+	require.True(t, si.Genesis)
+	require.Equal(t, 0, genesisState.Cmp(si.Info.State))
+}
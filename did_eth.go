@@ -0,0 +1,208 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+)
+
+const (
+	// DIDMethodPKH is did:pkh method. DIDs of eip155 namespace
+	// `did:pkh:eip155:<chainID>:<address>` are mapped to Ethereum-controlled
+	// iden3 IDs, see idFromEthDID.
+	// https://github.com/w3c-ccg/did-pkh/blob/main/did-pkh-method-draft.md
+	DIDMethodPKH DIDMethod = "pkh"
+	// DIDMethodEthr is did:ethr method. DIDs `did:ethr:[<network>:]<address>`
+	// are mapped to Ethereum-controlled iden3 IDs, see idFromEthDID.
+	// https://github.com/decentralized-identity/ethr-did-resolver/blob/master/doc/did-method-spec.md
+	DIDMethodEthr DIDMethod = "ethr"
+
+	pkhNamespaceEIP155 = "eip155"
+)
+
+// ethrNetworks is a map of did:ethr network names to chain IDs. Other
+// networks may be referenced by `0x` prefixed hex chain ID.
+var ethrNetworks = map[string]ChainID{
+	"mainnet": 1,
+	"goerli":  5,
+	"sepolia": 11155111,
+	"polygon": 137,
+	"mumbai":  80001,
+}
+
+// idFromEthDID returns Ethereum-controlled ID of did:pkh or did:ethr DID.
+// ErrMethodUnknown is returned if the DID is not identified by Ethereum
+// address (did:pkh of other namespaces, did:ethr of public key), then it is
+// handled as any other unsupported DID. ErrIncorrectDID is returned if the
+// DID is malformed or the address checksum is invalid.
+// ErrNetworkNotSupportedForDID is returned if the chain has no iden3
+// blockchain and network, so the DID never gets the ID that may change
+// when the chain is supported.
+func idFromEthDID(did w3c.DID) (ID, error) {
+	chainID, addrStr, err := ethDIDParts(did)
+	if err != nil {
+		return ID{}, err
+	}
+
+	blockchain, networkID, err := NetworkByChainID(chainID)
+	if err != nil {
+		return ID{}, err
+	}
+
+	addr, err := ParseEthAddress(addrStr)
+	if err != nil {
+		return ID{}, fmt.Errorf("%w: %v", ErrIncorrectDID, err)
+	}
+
+	typ, err := BuildDIDType(DIDMethodIden3, blockchain, networkID)
+	if err != nil {
+		return ID{}, err
+	}
+
+	return NewIDFromEthAddress(typ, addr), nil
+}
+
+// ethDIDParts returns chain ID and address string of did:pkh or did:ethr
+// DID
+func ethDIDParts(did w3c.DID) (ChainID, string, error) {
+	var (
+		chainID ChainID
+		addrStr string
+		err     error
+	)
+
+	switch DIDMethod(did.Method) {
+	case DIDMethodPKH:
+		if len(did.IDStrings) == 0 ||
+			did.IDStrings[0] != pkhNamespaceEIP155 {
+
+			return 0, "", fmt.Errorf("%w: not eip155 did:pkh",
+				ErrMethodUnknown)
+		}
+		if len(did.IDStrings) != 3 {
+			return 0, "", fmt.Errorf("%w: unexpected number of ID strings",
+				ErrIncorrectDID)
+		}
+		chainID, err = parseChainID(did.IDStrings[1], 10)
+		if err != nil {
+			return 0, "", fmt.Errorf("%w: invalid chain ID: %v",
+				ErrIncorrectDID, err)
+		}
+		addrStr = did.IDStrings[2]
+	case DIDMethodEthr:
+		switch len(did.IDStrings) {
+		case 1:
+			chainID = ethrNetworks["mainnet"]
+		case 2:
+			network := did.IDStrings[0]
+			var ok bool
+			chainID, ok = ethrNetworks[network]
+			if !ok {
+				if !strings.HasPrefix(network, "0x") {
+					return 0, "", fmt.Errorf(
+						"%w: unknown did:ethr network %v",
+						ErrNetworkNotSupportedForDID, network)
+				}
+				chainID, err = parseChainID(network[2:], 16)
+				if err != nil {
+					return 0, "", fmt.Errorf("%w: invalid chain ID: %v",
+						ErrIncorrectDID, err)
+				}
+			}
+		default:
+			return 0, "", fmt.Errorf("%w: unexpected number of ID strings",
+				ErrIncorrectDID)
+		}
+		addrStr = did.IDStrings[len(did.IDStrings)-1]
+		// did:ethr may be identified by public key instead of address
+		if len(addrStr) != 2+2*20 {
+			return 0, "", fmt.Errorf("%w: did:ethr is not an address",
+				ErrMethodUnknown)
+		}
+	default:
+		return 0, "", fmt.Errorf("%w: not did:pkh or did:ethr",
+			ErrMethodUnknown)
+	}
+
+	return chainID, addrStr, nil
+}
+
+func parseChainID(s string, base int) (ChainID, error) {
+	v, err := strconv.ParseInt(s, base, 32)
+	if err != nil {
+		return 0, err
+	}
+	if v <= 0 {
+		return 0, errors.New("chain ID must be positive")
+	}
+	return ChainID(v), nil
+}
+
+// PKHDIDFromID returns `did:pkh:eip155:<chainID>:<address>` DID of
+// Ethereum-controlled identity. The address is EIP-55 checksummed.
+func PKHDIDFromID(id ID) (*w3c.DID, error) {
+	chainID, addr, err := ethAddressAndChainFromID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	idStrings := []string{pkhNamespaceEIP155,
+		strconv.FormatInt(int64(chainID), 10), EthAddressToString(addr)}
+	return &w3c.DID{
+		Method:    string(DIDMethodPKH),
+		ID:        strings.Join(idStrings, ":"),
+		IDStrings: idStrings,
+	}, nil
+}
+
+// EthrDIDFromID returns `did:ethr:<network>:<address>` DID of
+// Ethereum-controlled identity. The network is omitted for mainnet, it is
+// hex chain ID if the network has no name. The address is EIP-55
+// checksummed.
+func EthrDIDFromID(id ID) (*w3c.DID, error) {
+	chainID, addr, err := ethAddressAndChainFromID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var idStrings []string
+	if chainID != ethrNetworks["mainnet"] {
+		network := "0x" + strconv.FormatInt(int64(chainID), 16)
+		for name, netChainID := range ethrNetworks {
+			if netChainID == chainID {
+				network = name
+				break
+			}
+		}
+		idStrings = append(idStrings, network)
+	}
+	idStrings = append(idStrings, EthAddressToString(addr))
+
+	return &w3c.DID{
+		Method:    string(DIDMethodEthr),
+		ID:        strings.Join(idStrings, ":"),
+		IDStrings: idStrings,
+	}, nil
+}
+
+func ethAddressAndChainFromID(id ID) (ChainID, [20]byte, error) {
+	if !IsEthAddressIdentity(id) {
+		return 0, [20]byte{}, errors.New(
+			"identity is not controlled by Ethereum address")
+	}
+
+	chainID, err := ChainIDFromID(id)
+	if err != nil {
+		return 0, [20]byte{}, err
+	}
+
+	addr, err := EthAddressFromID(id)
+	if err != nil {
+		return 0, [20]byte{}, err
+	}
+
+	return chainID, addr, nil
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/stretchr/testify/require"
+)
+
+const testEthAddress = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+func TestIDFromDID_PKH(t *testing.T) {
+	did, err := w3c.ParseDID("did:pkh:eip155:80001:" + testEthAddress)
+	require.NoError(t, err)
+
+	id, err := IDFromDID(*did)
+	require.NoError(t, err)
+	require.True(t, IsEthAddressIdentity(id))
+
+	addr, err := ParseEthAddress(testEthAddress)
+	require.NoError(t, err)
+	typ, err := BuildDIDType(DIDMethodIden3, Polygon, Mumbai)
+	require.NoError(t, err)
+	require.Equal(t, NewIDFromEthAddress(typ, addr), id)
+
+	chainID, err := ChainIDFromDID(*did)
+	require.NoError(t, err)
+	require.Equal(t, ChainID(80001), chainID)
+
+	did2, err := PKHDIDFromID(id)
+	require.NoError(t, err)
+	require.Equal(t, did.String(), did2.String())
+	require.Equal(t, did.IDStrings, did2.IDStrings)
+}
+
+func TestIDFromDID_Ethr(t *testing.T) {
+	addr, err := ParseEthAddress(testEthAddress)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		did        string
+		blockchain Blockchain
+		network    NetworkID
+		canonical  string
+	}{
+		{"did:ethr:" + testEthAddress, Ethereum, Main, ""},
+		{"did:ethr:mainnet:" + testEthAddress, Ethereum, Main,
+			"did:ethr:" + testEthAddress},
+		{"did:ethr:sepolia:" + testEthAddress, Ethereum, Sepolia, ""},
+		{"did:ethr:0x89:" + testEthAddress, Polygon, Main,
+			"did:ethr:polygon:" + testEthAddress},
+		{"did:ethr:0x44d:" + testEthAddress, ZkEVM, Main, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.did, func(t *testing.T) {
+			did, err := w3c.ParseDID(tc.did)
+			require.NoError(t, err)
+
+			id, err := IDFromDID(*did)
+			require.NoError(t, err)
+			typ, err := BuildDIDType(DIDMethodIden3, tc.blockchain,
+				tc.network)
+			require.NoError(t, err)
+			require.Equal(t, NewIDFromEthAddress(typ, addr), id)
+
+			canonical := tc.canonical
+			if canonical == "" {
+				canonical = tc.did
+			}
+			did2, err := EthrDIDFromID(id)
+			require.NoError(t, err)
+			require.Equal(t, canonical, did2.String())
+		})
+	}
+}
+
+func TestIDFromDID_EthFallback(t *testing.T) {
+	// DIDs that are not identified by Ethereum address are handled as other
+	// unsupported DIDs
+	dids := []string{
+		"did:pkh:solana:4sGjMW1sUnHzSxGspuhpqLDx6wiyjNtZ:CKg5d12Jhpej1JqtmxLJgaFqqeYjxgPqToJ4LBdvG9Ev",
+		"did:ethr:0x03fdd57adec3d438ea237fe46b33ee1e016eda6b585c3e27ea66686c2ea5358479",
+	}
+	for _, s := range dids {
+		did, err := w3c.ParseDID(s)
+		require.NoError(t, err)
+		id, err := IDFromDID(*did)
+		require.NoError(t, err, s)
+		require.Equal(t, newIDFromUnsupportedDID(*did), id, s)
+	}
+}
+
+func TestIDFromDID_EthErrors(t *testing.T) {
+	dids := []string{
+		// invalid checksum
+		"did:pkh:eip155:137:0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD",
+		"did:pkh:eip155:1:0xabc",
+		"did:pkh:eip155:0x1:" + testEthAddress,
+		"did:pkh:eip155:1",
+		"did:ethr:0xzz:" + testEthAddress,
+		"did:ethr:a:b:" + testEthAddress,
+	}
+	for _, s := range dids {
+		did, err := w3c.ParseDID(s)
+		require.NoError(t, err)
+		_, err = IDFromDID(*did)
+		require.True(t, errors.Is(err, ErrIncorrectDID), s)
+	}
+
+	// DIDs of unsupported chains don't get IDs that would change once the
+	// chain is supported
+	dids = []string{
+		"did:pkh:eip155:42161:" + testEthAddress,
+		"did:ethr:0xa4b1:" + testEthAddress,
+		"did:ethr:unknown:" + testEthAddress,
+	}
+	for _, s := range dids {
+		did, err := w3c.ParseDID(s)
+		require.NoError(t, err)
+		_, err = IDFromDID(*did)
+		require.True(t, errors.Is(err, ErrNetworkNotSupportedForDID), s)
+	}
+}
+
+func TestEthDIDFromID_Errors(t *testing.T) {
+	typ, err := BuildDIDType(DIDMethodIden3, Polygon, Mumbai)
+	require.NoError(t, err)
+	id, err := NewIDFromIdenState(typ, testIdenState(t, 1))
+	require.NoError(t, err)
+	_, err = PKHDIDFromID(*id)
+	require.EqualError(t, err,
+		"identity is not controlled by Ethereum address")
+
+	typ, err = BuildDIDType(DIDMethodIden3, ReadOnly, NoNetwork)
+	require.NoError(t, err)
+	addr, err := ParseEthAddress(testEthAddress)
+	require.NoError(t, err)
+	_, err = EthrDIDFromID(NewIDFromEthAddress(typ, addr))
+	require.True(t, errors.Is(err, ErrNetworkNotSupportedForDID), err)
+}
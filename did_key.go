@@ -0,0 +1,198 @@
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/mr-tron/base58"
+)
+
+const (
+	// DIDMethodKey is did:key method. did:key DIDs are not iden3 compatible,
+	// IDFromDID maps them to IDs of unsupported DIDs, that are stable for
+	// the same key.
+	// https://w3c-ccg.github.io/did-method-key/
+	DIDMethodKey DIDMethod = "key"
+
+	// MulticodecSecp256k1Pub is the multicodec code of compressed secp256k1
+	// public key.
+	// https://github.com/multiformats/multicodec/blob/master/table.csv
+	MulticodecSecp256k1Pub uint64 = 0xe7
+	// MulticodecBJJPub is the code of compressed BabyJubJub public key. There
+	// is no registered multicodec code for BabyJubJub keys, so the code from
+	// the private use range is used. did:key DIDs of BabyJubJub keys are not
+	// understood by other implementations.
+	MulticodecBJJPub uint64 = 0x300000
+
+	// MultikeyType is the type of verification method of did:key DID
+	// document.
+	// https://www.w3.org/TR/controller-document/#multikey
+	MultikeyType = "Multikey"
+	// MultikeyContext is the JSON-LD context of Multikey verification method
+	MultikeyContext = "https://w3id.org/security/multikey/v1"
+
+	// multibaseBase58BTC is the multibase prefix of base58btc encoding
+	multibaseBase58BTC = 'z'
+)
+
+// ErrInvalidDIDKey returns when did:key DID is malformed or the key type is
+// not supported.
+var ErrInvalidDIDKey = errors.New("invalid did:key")
+
+// DIDKey is a public key of did:key DID
+type DIDKey struct {
+	// Codec is the multicodec code of the key type: MulticodecBJJPub or
+	// MulticodecSecp256k1Pub.
+	Codec uint64
+	// PublicKey is the compressed public key
+	PublicKey []byte
+}
+
+// NewDIDKeyFromBJJ creates did:key DID of BabyJubJub public key
+func NewDIDKeyFromBJJ(pk *babyjub.PublicKey) (*w3c.DID, error) {
+	if pk == nil || !pk.Point().InCurve() {
+		return nil, fmt.Errorf("%w: invalid BabyJubJub public key",
+			ErrInvalidDIDKey)
+	}
+	comp := pk.Compress()
+	return DIDKey{Codec: MulticodecBJJPub, PublicKey: comp[:]}.DID()
+}
+
+// NewDIDKeyFromSecp256k1 creates did:key DID of compressed secp256k1 public
+// key
+func NewDIDKeyFromSecp256k1(compressed []byte) (*w3c.DID, error) {
+	return DIDKey{Codec: MulticodecSecp256k1Pub,
+		PublicKey: compressed}.DID()
+}
+
+// ParseDIDKey returns the public key of did:key DID
+func ParseDIDKey(did w3c.DID) (*DIDKey, error) {
+	if DIDMethod(did.Method) != DIDMethodKey {
+		return nil, fmt.Errorf("%w: method is not key", ErrInvalidDIDKey)
+	}
+	if len(did.IDStrings) != 1 {
+		return nil, fmt.Errorf("%w: unexpected number of ID strings",
+			ErrInvalidDIDKey)
+	}
+
+	mb := did.IDStrings[0]
+	if len(mb) == 0 || mb[0] != multibaseBase58BTC {
+		return nil, fmt.Errorf("%w: key is not base58btc encoded",
+			ErrInvalidDIDKey)
+	}
+	data, err := base58.Decode(mb[1:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDIDKey, err)
+	}
+
+	codec, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: invalid multicodec", ErrInvalidDIDKey)
+	}
+
+	k := &DIDKey{Codec: codec, PublicKey: data[n:]}
+	err = k.validate()
+	if err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// DID returns did:key DID of the key
+func (k DIDKey) DID() (*w3c.DID, error) {
+	mb, err := k.Multibase()
+	if err != nil {
+		return nil, err
+	}
+	return &w3c.DID{
+		Method:    string(DIDMethodKey),
+		ID:        mb,
+		IDStrings: []string{mb},
+	}, nil
+}
+
+// Multibase returns base58btc multibase encoded multicodec key, that is
+// used as did:key method-specific-id and as publicKeyMultibase of the
+// verification method.
+func (k DIDKey) Multibase() (string, error) {
+	err := k.validate()
+	if err != nil {
+		return "", err
+	}
+
+	data := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+
+		len(k.PublicKey))
+	n := binary.PutUvarint(data, k.Codec)
+	data = append(data[:n], k.PublicKey...)
+	return string(multibaseBase58BTC) + base58.Encode(data), nil
+}
+
+// BJJPublicKey returns BabyJubJub public key
+func (k DIDKey) BJJPublicKey() (*babyjub.PublicKey, error) {
+	if k.Codec != MulticodecBJJPub {
+		return nil, fmt.Errorf("%w: key is not BabyJubJub key",
+			ErrInvalidDIDKey)
+	}
+	var comp babyjub.PublicKeyComp
+	if len(k.PublicKey) != len(comp) {
+		return nil, fmt.Errorf("%w: invalid BabyJubJub key length",
+			ErrInvalidDIDKey)
+	}
+	copy(comp[:], k.PublicKey)
+	pk, err := comp.Decompress()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDIDKey, err)
+	}
+	return pk, nil
+}
+
+// Document returns DID document of did:key DID. The document has a single
+// Multikey verification method that is used for authentication, assertion,
+// capability invocation and delegation.
+func (k DIDKey) Document() (*w3c.DIDDocument, error) {
+	did, err := k.DID()
+	if err != nil {
+		return nil, err
+	}
+
+	vmID := *did
+	vmID.Fragment = did.ID
+	ref := []w3c.VerificationRelationship{{Reference: vmID}}
+
+	return &w3c.DIDDocument{
+		Context: []interface{}{w3c.DIDContextV1, MultikeyContext},
+		ID:      *did,
+		VerificationMethod: []w3c.VerificationMethod{
+			{
+				ID:                 vmID,
+				Type:               MultikeyType,
+				Controller:         *did,
+				PublicKeyMultibase: did.ID,
+			},
+		},
+		Authentication:       ref,
+		AssertionMethod:      ref,
+		CapabilityInvocation: ref,
+		CapabilityDelegation: ref,
+	}, nil
+}
+
+func (k DIDKey) validate() error {
+	switch k.Codec {
+	case MulticodecBJJPub:
+		_, err := k.BJJPublicKey()
+		return err
+	case MulticodecSecp256k1Pub:
+		_, err := parseSecp256k1PubKey(k.PublicKey)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidDIDKey, err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported key type 0x%x", ErrInvalidDIDKey,
+			k.Codec)
+	}
+}
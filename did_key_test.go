@@ -0,0 +1,127 @@
+package core
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/stretchr/testify/require"
+)
+
+func testBJJKey(t testing.TB) babyjub.PrivateKey {
+	t.Helper()
+	var k babyjub.PrivateKey
+	_, err := hex.Decode(k[:], []byte(
+		"28156abe7fe2fd433dc9df969286b96666489bac508612d0e16593e944c4f69f"))
+	require.NoError(t, err)
+	return k
+}
+
+func TestDIDKey_Secp256k1(t *testing.T) {
+	// test vector from did:key spec
+	did, err := w3c.ParseDID(
+		"did:key:zQ3shokFTS3brHcDQrn82RUDfCZESWL1ZdCEJwekUDPQiYBme")
+	require.NoError(t, err)
+
+	k, err := ParseDIDKey(*did)
+	require.NoError(t, err)
+	require.Equal(t, MulticodecSecp256k1Pub, k.Codec)
+	require.Len(t, k.PublicKey, 33)
+
+	did2, err := NewDIDKeyFromSecp256k1(k.PublicKey)
+	require.NoError(t, err)
+	require.Equal(t, did.String(), did2.String())
+
+	_, err = k.BJJPublicKey()
+	require.True(t, errors.Is(err, ErrInvalidDIDKey), err)
+}
+
+func TestDIDKey_BJJ(t *testing.T) {
+	privKey := testBJJKey(t)
+	pubKey := privKey.Public()
+
+	did, err := NewDIDKeyFromBJJ(pubKey)
+	require.NoError(t, err)
+
+	// the DID is parsed by the generic parser
+	did2, err := w3c.ParseDID(did.String())
+	require.NoError(t, err)
+	require.Equal(t, *did, *did2)
+
+	k, err := ParseDIDKey(*did2)
+	require.NoError(t, err)
+	require.Equal(t, MulticodecBJJPub, k.Codec)
+	pubKey2, err := k.BJJPublicKey()
+	require.NoError(t, err)
+	require.Equal(t, pubKey, pubKey2)
+
+	// ID of did:key is stable
+	id, err := IDFromDID(*did)
+	require.NoError(t, err)
+	id2, err := IDFromDID(*did2)
+	require.NoError(t, err)
+	require.Equal(t, id, id2)
+	require.Equal(t, newIDFromUnsupportedDID(*did), id)
+}
+
+func TestDIDKey_Document(t *testing.T) {
+	privKey := testBJJKey(t)
+	did, err := NewDIDKeyFromBJJ(privKey.Public())
+	require.NoError(t, err)
+	k, err := ParseDIDKey(*did)
+	require.NoError(t, err)
+
+	doc, err := k.Document()
+	require.NoError(t, err)
+
+	vmID := did.String() + "#" + did.ID
+	docJSON := `{
+  "@context": ["https://www.w3.org/ns/did/v1",
+    "https://w3id.org/security/multikey/v1"],
+  "id": "` + did.String() + `",
+  "verificationMethod": [{
+    "id": "` + vmID + `",
+    "type": "Multikey",
+    "controller": "` + did.String() + `",
+    "publicKeyMultibase": "` + did.ID + `"
+  }],
+  "authentication": ["` + vmID + `"],
+  "assertionMethod": ["` + vmID + `"],
+  "capabilityInvocation": ["` + vmID + `"],
+  "capabilityDelegation": ["` + vmID + `"]
+}`
+	requireJSONEqual(t, json.RawMessage(docJSON), doc)
+
+	// the verification method is found by the fragment
+	vm := doc.VerificationMethod[0]
+	require.Equal(t, did.ID, vm.ID.Fragment)
+}
+
+func TestParseDIDKey_Errors(t *testing.T) {
+	testCases := []string{
+		"did:example:zQ3shokFTS3brHcDQrn82RUDfCZESWL1ZdCEJwekUDPQiYBme",
+		"did:key:a:zQ3shokFTS3brHcDQrn82RUDfCZESWL1ZdCEJwekUDPQiYBme",
+		// base58 without multibase prefix
+		"did:key:Q3shokFTS3brHcDQrn82RUDfCZESWL1ZdCEJwekUDPQiYBme",
+		// invalid base58
+		"did:key:z0OIl",
+		// ed25519 key is not supported
+		"did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK",
+		// broken secp256k1 key
+		"did:key:zQ3shokFTS3brHcDQrn82RUDfCZESWL1ZdCEJwekUDPQiYBm",
+	}
+	for _, s := range testCases {
+		did, err := w3c.ParseDID(s)
+		require.NoError(t, err)
+		_, err = ParseDIDKey(*did)
+		require.True(t, errors.Is(err, ErrInvalidDIDKey), s)
+	}
+
+	_, err := NewDIDKeyFromSecp256k1([]byte{2, 1, 2, 3})
+	require.True(t, errors.Is(err, ErrInvalidDIDKey), err)
+	_, err = NewDIDKeyFromBJJ(nil)
+	require.True(t, errors.Is(err, ErrInvalidDIDKey), err)
+}
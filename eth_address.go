@@ -0,0 +1,104 @@
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/iden3/go-iden3-crypto/keccak256"
+)
+
+var (
+	// ErrInvalidEthAddress returns when Ethereum address string is not
+	// `0x` prefixed 40 hex digits.
+	ErrInvalidEthAddress = errors.New("invalid Ethereum address")
+	// ErrInvalidEthAddressChecksum returns when mixed-case Ethereum address
+	// string has invalid EIP-55 checksum.
+	ErrInvalidEthAddressChecksum = errors.New(
+		"invalid Ethereum address checksum")
+)
+
+// NewIDFromEthAddress creates new ID of the identity controlled by Ethereum
+// address. The genesis is the address prefixed with zero bytes, see
+// GenesisFromEthAddress.
+func NewIDFromEthAddress(typ [2]byte, addr [20]byte) ID {
+	return NewID(typ, GenesisFromEthAddress(addr))
+}
+
+// NewDIDFromEthAddress creates new DID of the identity controlled by
+// Ethereum address.
+func NewDIDFromEthAddress(typ [2]byte, addr [20]byte) (*w3c.DID, error) {
+	return ParseDIDFromID(NewIDFromEthAddress(typ, addr))
+}
+
+// EthAddressFromDID returns Ethereum address of the identity controlled by
+// Ethereum address.
+func EthAddressFromDID(did w3c.DID) ([20]byte, error) {
+	id, err := IDFromDID(did)
+	if err != nil {
+		return [20]byte{}, err
+	}
+	return EthAddressFromID(id)
+}
+
+// IsEthAddressIdentity returns true if the genesis of ID is Ethereum address
+// prefixed with zero bytes. Such identities are controlled by the address.
+func IsEthAddressIdentity(id ID) bool {
+	var z [genesisLn - 20]byte
+	return bytes.Equal(z[:], id[2:2+len(z)])
+}
+
+// ParseEthAddress parses `0x` prefixed hex Ethereum address. If the address
+// has both lower and upper case letters, EIP-55 checksum is verified.
+// https://eips.ethereum.org/EIPS/eip-55
+func ParseEthAddress(s string) ([20]byte, error) {
+	var addr [20]byte
+
+	if len(s) != 2+2*len(addr) || (s[:2] != "0x" && s[:2] != "0X") {
+		return addr, fmt.Errorf("%w: %q", ErrInvalidEthAddress, s)
+	}
+
+	hexAddr := s[2:]
+	_, err := hex.Decode(addr[:], []byte(hexAddr))
+	if err != nil {
+		return [20]byte{}, fmt.Errorf("%w: %q", ErrInvalidEthAddress, s)
+	}
+
+	if hexAddr != strings.ToLower(hexAddr) &&
+		hexAddr != strings.ToUpper(hexAddr) &&
+		EthAddressToString(addr)[2:] != hexAddr {
+
+		return [20]byte{}, fmt.Errorf("%w: %q", ErrInvalidEthAddressChecksum,
+			s)
+	}
+
+	return addr, nil
+}
+
+// EthAddressToString returns `0x` prefixed EIP-55 checksummed hex Ethereum
+// address.
+// https://eips.ethereum.org/EIPS/eip-55
+func EthAddressToString(addr [20]byte) string {
+	hexAddr := []byte(hex.EncodeToString(addr[:]))
+	hash := keccak256.Hash(hexAddr)
+
+	for i, c := range hexAddr {
+		if c < 'a' {
+			continue
+		}
+		// uppercase the letter if the corresponding nibble of the hash of
+		// the lower case address is 8 or more
+		nibble := hash[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if nibble&0xf >= 8 {
+			hexAddr[i] = c - 'a' + 'A'
+		}
+	}
+
+	return "0x" + string(hexAddr)
+}
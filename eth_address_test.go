@@ -0,0 +1,83 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEthAddressToString(t *testing.T) {
+	// test vectors from EIP-55
+	addresses := []string{
+		"0x52908400098527886E0F7030069857D2E4169EE7",
+		"0x8617E340B3D01FA5F11F306F4090FD50E238070D",
+		"0xde709f2102306220921060314715629080e2fb77",
+		"0x27b1fdb04752bbc536007a920d24acb045561c26",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	}
+	for _, s := range addresses {
+		addr, err := ParseEthAddress(s)
+		require.NoError(t, err)
+		require.Equal(t, s, EthAddressToString(addr))
+	}
+}
+
+func TestParseEthAddress(t *testing.T) {
+	want, err := ParseEthAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	require.NoError(t, err)
+
+	// lower and upper case addresses are not checksummed
+	addr, err := ParseEthAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
+	require.NoError(t, err)
+	require.Equal(t, want, addr)
+	addr, err = ParseEthAddress("0X5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED")
+	require.NoError(t, err)
+	require.Equal(t, want, addr)
+
+	_, err = ParseEthAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD")
+	require.True(t, errors.Is(err, ErrInvalidEthAddressChecksum), err)
+
+	invalid := []string{
+		"",
+		"5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1bea",
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaedaa",
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaez",
+	}
+	for _, s := range invalid {
+		_, err = ParseEthAddress(s)
+		require.True(t, errors.Is(err, ErrInvalidEthAddress), s)
+	}
+}
+
+func TestNewIDFromEthAddress(t *testing.T) {
+	addr, err := ParseEthAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	require.NoError(t, err)
+	typ, err := BuildDIDType(DIDMethodPolygonID, Polygon, Mumbai)
+	require.NoError(t, err)
+
+	id := NewIDFromEthAddress(typ, addr)
+	require.True(t, IsEthAddressIdentity(id))
+	require.Equal(t, typ, id.Type())
+	addr2, err := EthAddressFromID(id)
+	require.NoError(t, err)
+	require.Equal(t, addr, addr2)
+
+	did, err := NewDIDFromEthAddress(typ, addr)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(did.String(),
+		"did:polygonid:polygon:mumbai:"))
+	addr3, err := EthAddressFromDID(*did)
+	require.NoError(t, err)
+	require.Equal(t, addr, addr3)
+
+	// identity created from state is not controlled by address
+	stateID, err := NewIDFromIdenState(typ, testIdenState(t, 1))
+	require.NoError(t, err)
+	require.False(t, IsEthAddressIdentity(*stateID))
+}
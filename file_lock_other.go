@@ -0,0 +1,13 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+
+package core
+
+import (
+	"errors"
+	"os"
+)
+
+// lockFile returns an error, file locking is not supported on the platform
+func lockFile(_ *os.File) error {
+	return errors.New("file locking is not supported on this platform")
+}
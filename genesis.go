@@ -0,0 +1,89 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+)
+
+// GenesisStatus is a result of the genesis state check
+type GenesisStatus uint8
+
+const (
+	// GenesisStatusUnknown means the genesis state was not checked, it is
+	// returned along with an error.
+	GenesisStatusUnknown GenesisStatus = iota
+	// GenesisStatusUnsupported means the genesis state can't be checked for
+	// the DID, e.g. the DID method is not iden3 compatible.
+	GenesisStatusUnsupported
+	// GenesisStatusGenesis means the state is the genesis state of the DID.
+	GenesisStatusGenesis
+	// GenesisStatusNotGenesis means the state is not the genesis state of
+	// the DID.
+	GenesisStatusNotGenesis
+)
+
+// String returns the name of the genesis status
+func (s GenesisStatus) String() string {
+	switch s {
+	case GenesisStatusUnknown:
+		return "unknown"
+	case GenesisStatusUnsupported:
+		return "unsupported"
+	case GenesisStatusGenesis:
+		return "genesis"
+	case GenesisStatusNotGenesis:
+		return "not genesis"
+	default:
+		return fmt.Sprintf("GenesisStatus(%d)", uint8(s))
+	}
+}
+
+// CheckGenesisStateDID check if the state is genesis for the DID. Returns
+// ErrMethodUnknown for DIDs of methods that are not iden3 compatible.
+func CheckGenesisStateDID(did w3c.DID, state *big.Int) (bool, error) {
+	id, err := idFromDID(did)
+	if err != nil {
+		return false, err
+	}
+
+	return CheckGenesisStateID(id.BigInt(), state)
+}
+
+// CheckGenesisStateProfileDID checks if the DID is the identity created from
+// the genesis state or, if nonce is not empty, the profile of such identity
+// with the nonce. GenesisStatusUnsupported is returned for DIDs of methods
+// that are not iden3 compatible. Error along with GenesisStatusUnknown is
+// returned if the DID is malformed or the state is not valid.
+func CheckGenesisStateProfileDID(did w3c.DID, state,
+	nonce *big.Int) (GenesisStatus, error) {
+
+	id, err := idFromDID(did)
+	if errors.Is(err, ErrMethodUnknown) {
+		return GenesisStatusUnsupported, nil
+	}
+	if err != nil {
+		return GenesisStatusUnknown, err
+	}
+
+	if state == nil {
+		return GenesisStatusUnknown, errors.New("state is nil")
+	}
+
+	identityID, err := NewIDFromIdenState(id.Type(), state)
+	if err != nil {
+		return GenesisStatusUnknown, err
+	}
+
+	profileID, err := ProfileID(*identityID, nonce)
+	if err != nil {
+		return GenesisStatusUnknown, err
+	}
+
+	if !profileID.Equal(&id) {
+		return GenesisStatusNotGenesis, nil
+	}
+	return GenesisStatusGenesis, nil
+}
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckGenesisStateDID(t *testing.T) {
+	typ, err := BuildDIDType(DIDMethodPolygonID, Polygon, Mumbai)
+	require.NoError(t, err)
+	state := testIdenState(t, 1)
+	did, err := NewDIDFromIdenState(typ, state)
+	require.NoError(t, err)
+
+	ok, err := CheckGenesisStateDID(*did, state)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = CheckGenesisStateDID(*did, testIdenState(t, 2))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	did, err = w3c.ParseDID("did:example:123")
+	require.NoError(t, err)
+	_, err = CheckGenesisStateDID(*did, state)
+	require.ErrorIs(t, err, ErrMethodUnknown)
+}
+
+func TestCheckGenesisStateProfileDID(t *testing.T) {
+	typ, err := BuildDIDType(DIDMethodPolygonID, Polygon, Mumbai)
+	require.NoError(t, err)
+	state := testIdenState(t, 1)
+	did, err := NewDIDFromIdenState(typ, state)
+	require.NoError(t, err)
+	nonce := big.NewInt(10)
+	profileDID, err := ProfileDID(*did, nonce)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name  string
+		did   *w3c.DID
+		state *big.Int
+		nonce *big.Int
+		want  GenesisStatus
+	}{
+		{"identity", did, state, nil, GenesisStatusGenesis},
+		{"identity with zero nonce", did, state, big.NewInt(0),
+			GenesisStatusGenesis},
+		{"identity with nonce", did, state, nonce, GenesisStatusNotGenesis},
+		{"identity with other state", did, testIdenState(t, 2), nil,
+			GenesisStatusNotGenesis},
+		{"profile", profileDID, state, nonce, GenesisStatusGenesis},
+		{"profile without nonce", profileDID, state, nil,
+			GenesisStatusNotGenesis},
+		{"profile with other nonce", profileDID, state, big.NewInt(11),
+			GenesisStatusNotGenesis},
+		{"profile with other state", profileDID, testIdenState(t, 2), nonce,
+			GenesisStatusNotGenesis},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := CheckGenesisStateProfileDID(*tc.did, tc.state,
+				tc.nonce)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got, got.String())
+		})
+	}
+}
+
+func TestCheckGenesisStateProfileDID_Errors(t *testing.T) {
+	did, err := w3c.ParseDID("did:example:123")
+	require.NoError(t, err)
+	got, err := CheckGenesisStateProfileDID(*did, big.NewInt(1), nil)
+	require.NoError(t, err)
+	require.Equal(t, GenesisStatusUnsupported, got)
+
+	did, err = w3c.ParseDID("did:polygonid:polygon:mumbai:123")
+	require.NoError(t, err)
+	got, err = CheckGenesisStateProfileDID(*did, big.NewInt(1), nil)
+	require.True(t, errors.Is(err, ErrIncorrectDID), err)
+	require.Equal(t, GenesisStatusUnknown, got)
+
+	did, err = w3c.ParseDID(
+		"did:polygonid:polygon:mumbai:2qEw5HAQFBh6aavAufixuUXY6J9ip4MiuH3yJbAuer")
+	require.NoError(t, err)
+	got, err = CheckGenesisStateProfileDID(*did, nil, nil)
+	require.EqualError(t, err, "state is nil")
+	require.Equal(t, GenesisStatusUnknown, got)
+}
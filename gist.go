@@ -0,0 +1,218 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// GistLevels is the number of levels of Global Identity State Tree, that is
+// used by iden3 circuits.
+const GistLevels = 64
+
+// ErrGistRootNotFound returns when GIST root is unknown
+var ErrGistRootNotFound = errors.New("GIST root not found")
+
+// StateReader reads the latest identity states and Global Identity State
+// Tree (GIST). It is implemented by GistStore and may be implemented by the
+// client of State contract.
+type StateReader interface {
+	// GistRoot returns the current GIST root
+	GistRoot(ctx context.Context) (*big.Int, error)
+	// GistRootInfoByRoot returns the info of current or historical GIST
+	// root. Returns ErrGistRootNotFound if the root is unknown.
+	GistRootInfoByRoot(ctx context.Context, root *big.Int) (*GistRootInfo,
+		error)
+	// State returns the latest state of ID. Returns ErrStateNotFound if ID
+	// has no state in GIST.
+	State(ctx context.Context, id ID) (*big.Int, error)
+	// GistProof returns the proof of inclusion or non-inclusion of ID in
+	// GIST of the root. The current root is used if the root is nil.
+	GistProof(ctx context.Context, id ID, root *big.Int) (*GistProof, error)
+}
+
+// GistProof is the proof of inclusion or non-inclusion of ID in GIST
+type GistProof struct {
+	Root  *big.Int
+	Proof *MerkleTreeProof
+	// State is the state of ID, zero for the proof of non-inclusion
+	State *big.Int
+}
+
+// Verify verifies the proof of ID against the root of the proof
+func (p *GistProof) Verify(id ID) bool {
+	return VerifyMerkleTreeProof(p.Root, p.Proof, id.BigInt(), p.State)
+}
+
+// GistStoreOption is an option of NewGistStore
+type GistStoreOption func(*GistStore)
+
+// WithGistClock sets the clock that is used for root timestamps. time.Now
+// is used by default.
+func WithGistClock(now func() time.Time) GistStoreOption {
+	return func(g *GistStore) {
+		g.now = now
+	}
+}
+
+// GistStore is Global Identity State Tree (GIST) that keeps the latest state
+// of each ID keyed by ID.BigInt() in MerkleTree and the history of its roots
+// in memory. It is a local stand-in for State contract. GistStore is safe for
+// concurrent use, the tree must not be changed by others.
+type GistStore struct {
+	mu    sync.RWMutex
+	tree  MerkleTree
+	now   func() time.Time
+	roots []*GistRootInfo
+	// rootsIdx is the index of root in roots by its decimal representation
+	rootsIdx map[string]int
+}
+
+// NewGistStore creates new GistStore on top of the empty tree of GistLevels
+// levels
+func NewGistStore(tree MerkleTree, opts ...GistStoreOption) (*GistStore,
+	error) {
+
+	if tree == nil {
+		return nil, errors.New("GIST tree is not set")
+	}
+	if tree.Root().Sign() != 0 {
+		return nil, errors.New("GIST tree is not empty")
+	}
+
+	g := &GistStore{tree: tree, now: time.Now, rootsIdx: make(map[string]int)}
+	for _, opt := range opts {
+		opt(g)
+	}
+	g.addRoot(tree.Root())
+	return g, nil
+}
+
+// Update sets the latest state of ID
+func (g *GistStore) Update(ctx context.Context, id ID, state *big.Int) error {
+	if state == nil || state.Sign() == 0 {
+		return errors.New("state must be non-zero")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	k := id.BigInt()
+	proof, _, err := g.tree.GenerateProof(ctx, k, nil)
+	if err != nil {
+		return err
+	}
+	if proof.Existence {
+		err = g.tree.Update(ctx, k, state)
+	} else {
+		err = g.tree.Add(ctx, k, state)
+	}
+	if err != nil {
+		return err
+	}
+
+	root := g.tree.Root()
+	if root.Cmp(g.roots[len(g.roots)-1].Root) != 0 {
+		g.addRoot(root)
+	}
+	return nil
+}
+
+func (g *GistStore) addRoot(root *big.Int) {
+	info := &GistRootInfo{
+		Root:               copyBigInt(root),
+		ReplacedByRoot:     big.NewInt(0),
+		CreatedAtTimestamp: uint64(g.now().Unix()),
+	}
+	if len(g.roots) > 0 {
+		prev := g.roots[len(g.roots)-1]
+		prev.ReplacedByRoot = copyBigInt(root)
+		prev.ReplacedAtTimestamp = info.CreatedAtTimestamp
+	}
+	g.rootsIdx[root.String()] = len(g.roots)
+	g.roots = append(g.roots, info)
+}
+
+// GistRoot returns the current GIST root
+func (g *GistStore) GistRoot(_ context.Context) (*big.Int, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.tree.Root(), nil
+}
+
+// GistRootInfoByRoot returns the info of current or historical GIST root
+func (g *GistStore) GistRootInfoByRoot(_ context.Context,
+	root *big.Int) (*GistRootInfo, error) {
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if root == nil {
+		return nil, ErrGistRootNotFound
+	}
+	idx, ok := g.rootsIdx[root.String()]
+	if !ok {
+		return nil, ErrGistRootNotFound
+	}
+	return copyGistRootInfo(g.roots[idx]), nil
+}
+
+// RootHistory returns all GIST roots from the empty tree root to the
+// current root. ReplacedByRoot of the current root is zero.
+func (g *GistStore) RootHistory() []GistRootInfo {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	history := make([]GistRootInfo, len(g.roots))
+	for i, info := range g.roots {
+		history[i] = *copyGistRootInfo(info)
+	}
+	return history
+}
+
+// copyGistRootInfo returns deep copy of the info
+func copyGistRootInfo(info *GistRootInfo) *GistRootInfo {
+	c := *info
+	c.Root = copyBigInt(info.Root)
+	c.ReplacedByRoot = copyBigInt(info.ReplacedByRoot)
+	return &c
+}
+
+// State returns the latest state of ID
+func (g *GistStore) State(ctx context.Context, id ID) (*big.Int, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	proof, state, err := g.tree.GenerateProof(ctx, id.BigInt(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if !proof.Existence {
+		return nil, ErrStateNotFound
+	}
+	return state, nil
+}
+
+// GistProof returns the proof of inclusion or non-inclusion of ID in GIST
+// of the root. The current root is used if the root is nil.
+func (g *GistStore) GistProof(ctx context.Context, id ID,
+	root *big.Int) (*GistProof, error) {
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if root == nil {
+		root = g.tree.Root()
+	} else if _, ok := g.rootsIdx[root.String()]; !ok {
+		return nil, ErrGistRootNotFound
+	}
+
+	proof, state, err := g.tree.GenerateProof(ctx, id.BigInt(), root)
+	if err != nil {
+		return nil, err
+	}
+	return &GistProof{Root: new(big.Int).Set(root), Proof: proof,
+		State: state}, nil
+}
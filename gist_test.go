@@ -0,0 +1,194 @@
+package core
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testGistID(t testing.TB, clr int64) ID {
+	t.Helper()
+	typ, err := BuildDIDType(DIDMethodPolygonID, Polygon, Mumbai)
+	require.NoError(t, err)
+	id, err := NewIDFromIdenState(typ, testIdenState(t, clr))
+	require.NoError(t, err)
+	return *id
+}
+
+func TestGistStore(t *testing.T) {
+	ctx := context.Background()
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		ts = ts.Add(time.Minute)
+		return ts
+	}
+
+	gist, err := NewGistStore(newMemMerkleTree(GistLevels),
+		WithGistClock(clock))
+	require.NoError(t, err)
+	var _ StateReader = gist
+
+	emptyRoot, err := gist.GistRoot(ctx)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(0), emptyRoot)
+
+	id1 := testGistID(t, 1)
+	id2 := testGistID(t, 2)
+
+	_, err = gist.State(ctx, id1)
+	require.ErrorIs(t, err, ErrStateNotFound)
+
+	require.NoError(t, gist.Update(ctx, id1, testIdenState(t, 1)))
+	root1, err := gist.GistRoot(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, gist.Update(ctx, id2, testIdenState(t, 2)))
+	require.NoError(t, gist.Update(ctx, id1, testIdenState(t, 3)))
+	root3, err := gist.GistRoot(ctx)
+	require.NoError(t, err)
+
+	// the same state does not change the root
+	require.NoError(t, gist.Update(ctx, id1, testIdenState(t, 3)))
+
+	state, err := gist.State(ctx, id1)
+	require.NoError(t, err)
+	require.Equal(t, testIdenState(t, 3), state)
+
+	history := gist.RootHistory()
+	require.Len(t, history, 4)
+	require.Equal(t, emptyRoot, history[0].Root)
+	require.Equal(t, root1, history[1].Root)
+	require.Equal(t, root3, history[3].Root)
+	require.Equal(t, big.NewInt(0), history[3].ReplacedByRoot)
+	require.Equal(t, uint64(0), history[3].ReplacedAtTimestamp)
+	require.Equal(t, history[2].Root, history[1].ReplacedByRoot)
+	require.Equal(t, history[2].CreatedAtTimestamp,
+		history[1].ReplacedAtTimestamp)
+	require.Equal(t, uint64(1672531260), history[0].CreatedAtTimestamp)
+	require.Equal(t, uint64(1672531320), history[1].CreatedAtTimestamp)
+
+	info, err := gist.GistRootInfoByRoot(ctx, root1)
+	require.NoError(t, err)
+	require.Equal(t, history[1], *info)
+	_, err = gist.GistRootInfoByRoot(ctx, big.NewInt(1))
+	require.ErrorIs(t, err, ErrGistRootNotFound)
+	_, err = gist.GistRootInfoByRoot(ctx, nil)
+	require.ErrorIs(t, err, ErrGistRootNotFound)
+
+	// proof of inclusion of the latest state
+	proof, err := gist.GistProof(ctx, id1, nil)
+	require.NoError(t, err)
+	require.Equal(t, root3, proof.Root)
+	require.True(t, proof.Proof.Existence)
+	require.Equal(t, testIdenState(t, 3), proof.State)
+	require.True(t, proof.Verify(id1))
+	require.False(t, proof.Verify(id2))
+
+	// proof of inclusion of the old state in the historical root
+	proof, err = gist.GistProof(ctx, id1, root1)
+	require.NoError(t, err)
+	require.Equal(t, testIdenState(t, 1), proof.State)
+	require.True(t, proof.Verify(id1))
+
+	// proof of non-inclusion
+	proof, err = gist.GistProof(ctx, id2, root1)
+	require.NoError(t, err)
+	require.False(t, proof.Proof.Existence)
+	require.Equal(t, big.NewInt(0), proof.State)
+	require.True(t, proof.Verify(id2))
+
+	id3 := testGistID(t, 4)
+	proof, err = gist.GistProof(ctx, id3, nil)
+	require.NoError(t, err)
+	require.False(t, proof.Proof.Existence)
+	require.True(t, proof.Verify(id3))
+
+	_, err = gist.GistProof(ctx, id1, big.NewInt(1))
+	require.ErrorIs(t, err, ErrGistRootNotFound)
+
+	require.Error(t, gist.Update(ctx, id1, big.NewInt(0)))
+	require.Error(t, gist.Update(ctx, id1, nil))
+}
+
+func TestGistStore_DeepCopy(t *testing.T) {
+	ctx := context.Background()
+	gist, err := NewGistStore(newMemMerkleTree(GistLevels))
+	require.NoError(t, err)
+	require.NoError(t, gist.Update(ctx, testGistID(t, 1), testIdenState(t, 1)))
+	root1, err := gist.GistRoot(ctx)
+	require.NoError(t, err)
+	require.NoError(t, gist.Update(ctx, testGistID(t, 2), testIdenState(t, 2)))
+	root2, err := gist.GistRoot(ctx)
+	require.NoError(t, err)
+
+	// the returned infos do not share numbers with the store
+	info, err := gist.GistRootInfoByRoot(ctx, root1)
+	require.NoError(t, err)
+	info.Root.SetInt64(0)
+	info.ReplacedByRoot.SetInt64(0)
+	for _, info := range gist.RootHistory() {
+		info.Root.SetInt64(0)
+		info.ReplacedByRoot.SetInt64(0)
+	}
+	root2.SetInt64(0)
+
+	info, err = gist.GistRootInfoByRoot(ctx, root1)
+	require.NoError(t, err)
+	require.Equal(t, root1, info.Root)
+	root2, err = gist.GistRoot(ctx)
+	require.NoError(t, err)
+	require.Equal(t, root2, info.ReplacedByRoot)
+
+	// the replacing root of the previous info is not the root of the next
+	// info
+	history := gist.RootHistory()
+	require.Len(t, history, 3)
+	require.Equal(t, big.NewInt(0), history[0].Root)
+	require.Equal(t, root1, history[1].Root)
+	require.Equal(t, root2, history[2].Root)
+	info, err = gist.GistRootInfoByRoot(ctx, root2)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(0), info.ReplacedByRoot)
+}
+
+func TestNewGistStore_Errors(t *testing.T) {
+	_, err := NewGistStore(nil)
+	require.Error(t, err)
+
+	tree := newMemMerkleTree(GistLevels)
+	require.NoError(t, tree.Add(context.Background(), big.NewInt(1),
+		big.NewInt(1)))
+	_, err = NewGistStore(tree)
+	require.Error(t, err)
+}
+
+func TestGistStore_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	gist, err := NewGistStore(newMemMerkleTree(GistLevels))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := int64(1); i <= 8; i++ {
+		wg.Add(1)
+		go func(i int64) {
+			defer wg.Done()
+			id := testGistID(t, i)
+			require.NoError(t, gist.Update(ctx, id, testIdenState(t, i)))
+			proof, err := gist.GistProof(ctx, id, nil)
+			require.NoError(t, err)
+			require.True(t, proof.Verify(id))
+		}(i)
+	}
+	wg.Wait()
+
+	require.Len(t, gist.RootHistory(), 9)
+	for i := int64(1); i <= 8; i++ {
+		state, err := gist.State(ctx, testGistID(t, i))
+		require.NoError(t, err)
+		require.Equal(t, testIdenState(t, i), state)
+	}
+}
@@ -3,14 +3,17 @@ module github.com/iden3/go-iden3-core/v2
 go 1.18
 
 require (
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.2.0
 	github.com/iden3/go-iden3-crypto v0.0.15
 	github.com/mr-tron/base58 v1.2.0
 	github.com/stretchr/testify v1.8.2
+	golang.org/x/sys v0.6.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/dchest/blake512 v1.0.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/sys v0.6.0 // indirect
+	golang.org/x/crypto v0.7.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -2,8 +2,10 @@ package core
 
 import (
 	"bytes"
+	"database/sql/driver"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/iden3/go-iden3-crypto/poseidon"
@@ -109,6 +111,37 @@ func (id *ID) UnmarshalText(b []byte) error {
 	return err
 }
 
+// Value implements driver.Valuer interface. ID is stored as base58 string.
+func (id ID) Value() (driver.Value, error) {
+	return id.String(), nil
+}
+
+// Scan implements sql.Scanner interface. ID may be scanned from base58
+// string or from 31 raw bytes.
+func (id *ID) Scan(src interface{}) error {
+	var (
+		newID ID
+		err   error
+	)
+	switch v := src.(type) {
+	case string:
+		newID, err = IDFromString(v)
+	case []byte:
+		if len(v) == len(id) {
+			newID, err = IDFromBytes(v)
+		} else {
+			newID, err = IDFromString(string(v))
+		}
+	default:
+		return fmt.Errorf("can't scan ID from %T", src)
+	}
+	if err != nil {
+		return err
+	}
+	*id = newID
+	return nil
+}
+
 func (id *ID) Equals(id2 *ID) bool {
 	return bytes.Equal(id[:], id2[:])
 }
@@ -241,3 +241,29 @@ func TestID_Type(t *testing.T) {
 
 	require.Equal(t, id.Type(), [2]byte{0x00, 0x01})
 }
+
+func TestID_SQL(t *testing.T) {
+	id, err := IDFromString("11AVZrKNJVqDJoyKrdyaAgEynyBEjksV5z2NjZogFv")
+	require.NoError(t, err)
+
+	v, err := id.Value()
+	require.NoError(t, err)
+	require.Equal(t, "11AVZrKNJVqDJoyKrdyaAgEynyBEjksV5z2NjZogFv", v)
+
+	var id2 ID
+	require.NoError(t, id2.Scan(v))
+	require.Equal(t, id, id2)
+
+	var id3 ID
+	require.NoError(t, id3.Scan([]byte(v.(string))))
+	require.Equal(t, id, id3)
+
+	var id4 ID
+	require.NoError(t, id4.Scan(id.Bytes()))
+	require.Equal(t, id, id4)
+
+	var id5 ID
+	require.EqualError(t, id5.Scan(nil), "can't scan ID from <nil>")
+	require.Error(t, id5.Scan("not-an-id"))
+	require.Equal(t, ID{}, id5)
+}
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+)
+
+// IdentityTreeLevels is the number of levels of claims, revocation and roots
+// trees of identity, that is used by iden3 circuits.
+const IdentityTreeLevels = 40
+
+// Identity is iden3 identity with its claims, revocation and roots trees
+type Identity struct {
+	ID  ID
+	DID *w3c.DID
+	// GenesisState is the state of identity after creation. ID is derived
+	// from it.
+	GenesisState *big.Int
+	// AuthClaim is the claim of BabyJubJub auth key of identity
+	AuthClaim *Claim
+	// AuthClaimProof is the proof of inclusion of AuthClaim in the genesis
+	// claims tree
+	AuthClaimProof *MerkleTreeProof
+
+	IdentityTrees
+}
+
+// IdentityTrees are the claims, revocation and roots trees of identity
+type IdentityTrees struct {
+	ClaimsTree     MerkleTree
+	RevocationTree MerkleTree
+	RootsTree      MerkleTree
+}
+
+type identityOptions struct {
+	revNonce uint64
+}
+
+// IdentityOption is an option of NewIdentityFromAuthKey
+type IdentityOption func(*identityOptions) error
+
+// WithAuthClaimRevocationNonce sets revocation nonce of auth claim. It is
+// zero by default.
+func WithAuthClaimRevocationNonce(nonce uint64) IdentityOption {
+	return func(o *identityOptions) error {
+		o.revNonce = nonce
+		return nil
+	}
+}
+
+// NewIdentityFromAuthKey creates new identity with single auth claim of the
+// BabyJubJub public key in the claims tree. Revocation and roots trees are
+// empty. The genesis state is IdenState of the tree roots, and the ID is
+// derived from it with type of method, blockchain and network. The trees
+// must be empty, the auth claim is added to the claims tree.
+func NewIdentityFromAuthKey(ctx context.Context, pubKey *babyjub.PublicKey,
+	method DIDMethod, blockchain Blockchain, network NetworkID,
+	trees IdentityTrees, opts ...IdentityOption) (*Identity, error) {
+
+	if pubKey == nil || !pubKey.Point().InCurve() {
+		return nil, errors.New("invalid BabyJubJub public key")
+	}
+	for _, tree := range []MerkleTree{trees.ClaimsTree, trees.RevocationTree,
+		trees.RootsTree} {
+
+		if tree == nil {
+			return nil, errors.New("identity tree is not set")
+		}
+		if tree.Root().Sign() != 0 {
+			return nil, errors.New("identity tree is not empty")
+		}
+	}
+
+	o := identityOptions{}
+	for _, opt := range opts {
+		err := opt(&o)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	typ, err := BuildDIDType(method, blockchain, network)
+	if err != nil {
+		return nil, err
+	}
+
+	authClaim, err := NewClaim(AuthSchemaHash,
+		WithIndexDataInts(pubKey.X, pubKey.Y),
+		WithRevocationNonce(o.revNonce))
+	if err != nil {
+		return nil, err
+	}
+
+	i := &Identity{AuthClaim: authClaim, IdentityTrees: trees}
+
+	hi, hv, err := authClaim.HiHv()
+	if err != nil {
+		return nil, err
+	}
+	err = i.ClaimsTree.Add(ctx, hi, hv)
+	if err != nil {
+		return nil, err
+	}
+	i.AuthClaimProof, _, err = i.ClaimsTree.GenerateProof(ctx, hi, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	i.GenesisState, err = i.State()
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := NewIDFromIdenState(typ, i.GenesisState)
+	if err != nil {
+		return nil, err
+	}
+	i.ID = *id
+
+	i.DID, err = ParseDIDFromID(i.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
+
+// Roots returns the current roots of identity trees
+func (i *Identity) Roots() TreeRoots {
+	return TreeRoots{
+		ClaimsTreeRoot:     i.ClaimsTree.Root(),
+		RevocationTreeRoot: i.RevocationTree.Root(),
+		RootsTreeRoot:      i.RootsTree.Root(),
+	}
+}
+
+// State returns the current state of identity, that is IdenState of the
+// current roots of identity trees.
+func (i *Identity) State() (*big.Int, error) {
+	return i.Roots().State()
+}
+
+// TreeRoots are the roots of claims, revocation and roots trees of identity
+// state
+type TreeRoots struct {
+	ClaimsTreeRoot     *big.Int
+	RevocationTreeRoot *big.Int
+	RootsTreeRoot      *big.Int
+}
+
+// State returns IdenState of the roots
+func (r TreeRoots) State() (*big.Int, error) {
+	if r.ClaimsTreeRoot == nil || r.RevocationTreeRoot == nil ||
+		r.RootsTreeRoot == nil {
+
+		return nil, errors.New("tree root is not set")
+	}
+	return IdenState(r.ClaimsTreeRoot, r.RevocationTreeRoot, r.RootsTreeRoot)
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIdentityFromAuthKey(t *testing.T) {
+	ctx := context.Background()
+	k := testBJJKey(t)
+
+	// Known answers of the key 28156abe…f69f with zero revocation nonce.
+	// They were derived outside of the code under test from the claim
+	// layout of the iden3 spec: hi is Poseidon of the index slots (auth
+	// schema hash, 0, X, Y), hv is Poseidon(0, 0, 0, 0), the claims root is
+	// Poseidon(hi, hv, 1), the state is Poseidon(claimsRoot, 0, 0) and the
+	// ID is the type, the 27 high bytes of the state in little-endian order
+	// and the checksum.
+	const (
+		wantHi         = "18949542467377370423320029419852398777739249443961169538665394462321297568083"
+		wantHv         = "2351654555892372227640888372176282444150254868378439619268573230312091195718"
+		wantClaimsRoot = "1048368801962331642023042555069110665696393985462136517017416674759938106047"
+		wantState      = "14009222343243302397788498587511347817923695654139133997358939776956416841936"
+		wantDID        = "did:polygonid:polygon:mumbai:2qD1RVQNeHkf4bmD3A8PMzANxEVWZ5LtcAHpaRrnhb"
+	)
+
+	identity, err := NewIdentityFromAuthKey(ctx, k.Public(),
+		DIDMethodPolygonID, Polygon, Mumbai, testIdentityTrees())
+	require.NoError(t, err)
+
+	hi, hv, err := identity.AuthClaim.HiHv()
+	require.NoError(t, err)
+	require.Equal(t, wantHi, hi.String())
+	require.Equal(t, wantHv, hv.String())
+	require.Equal(t, AuthSchemaHash, identity.AuthClaim.GetSchemaHash())
+	require.Equal(t, uint64(0), identity.AuthClaim.GetRevocationNonce())
+
+	require.Equal(t, wantClaimsRoot, identity.ClaimsTree.Root().String())
+	require.Equal(t, big.NewInt(0), identity.RevocationTree.Root())
+	require.Equal(t, big.NewInt(0), identity.RootsTree.Root())
+	require.Equal(t, wantState, identity.GenesisState.String())
+	require.Equal(t, wantDID, identity.DID.String())
+
+	id, err := IDFromDID(*identity.DID)
+	require.NoError(t, err)
+	require.Equal(t, id, identity.ID)
+
+	require.True(t, identity.AuthClaimProof.Existence)
+	require.True(t, VerifyMerkleTreeProof(identity.ClaimsTree.Root(),
+		identity.AuthClaimProof, hi, hv))
+
+	isGenesis, err := CheckGenesisStateDID(*identity.DID,
+		identity.GenesisState)
+	require.NoError(t, err)
+	require.True(t, isGenesis)
+
+	state, err := identity.State()
+	require.NoError(t, err)
+	require.Equal(t, identity.GenesisState, state)
+}
+
+func TestNewIdentityFromAuthKey_Options(t *testing.T) {
+	ctx := context.Background()
+	k := testBJJKey(t)
+
+	identity, err := NewIdentityFromAuthKey(ctx, k.Public(), DIDMethodIden3,
+		Polygon, Main, testIdentityTrees(), WithAuthClaimRevocationNonce(1))
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), identity.AuthClaim.GetRevocationNonce())
+
+	_, err = NewIdentityFromAuthKey(ctx, k.Public(), DIDMethodIden3, Polygon,
+		Sepolia, testIdentityTrees())
+	require.ErrorIs(t, err, ErrNetworkNotSupportedForDID)
+
+	_, err = NewIdentityFromAuthKey(ctx, nil, DIDMethodIden3, Polygon, Main,
+		testIdentityTrees())
+	require.Error(t, err)
+
+	notOnCurve := &babyjub.PublicKey{X: big.NewInt(1), Y: big.NewInt(1)}
+	_, err = NewIdentityFromAuthKey(ctx, notOnCurve, DIDMethodIden3, Polygon,
+		Main, testIdentityTrees())
+	require.Error(t, err)
+
+	// trees must be set and empty
+	trees := testIdentityTrees()
+	trees.RootsTree = nil
+	_, err = NewIdentityFromAuthKey(ctx, k.Public(), DIDMethodIden3, Polygon,
+		Main, trees)
+	require.Error(t, err)
+	_, err = NewIdentityFromAuthKey(ctx, k.Public(), DIDMethodIden3, Polygon,
+		Main, identity.IdentityTrees)
+	require.Error(t, err)
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+// MerkleTree is the sparse Merkle tree of iden3 identity trees (claims,
+// revocation and roots trees) and GIST. Leaf hash is Poseidon(key, value, 1),
+// middle node hash is Poseidon(left, right) and empty node hash is zero. The
+// path of the key is the bits of the key starting from the least significant
+// one, 0 is left and 1 is right.
+//
+// The tree of github.com/iden3/go-merkletree-sql can be used with a thin
+// adapter that converts its hashes and proofs.
+type MerkleTree interface {
+	// Root returns the current root of the tree
+	Root() *big.Int
+	// Add adds new entry to the tree. Returns an error if the key is
+	// already in the tree.
+	Add(ctx context.Context, k, v *big.Int) error
+	// Update sets the value of the entry of the tree. Returns an error if
+	// the key is not in the tree.
+	Update(ctx context.Context, k, v *big.Int) error
+	// GenerateProof generates the proof of existence or non-existence of the
+	// key in the tree of the given root. The current root is used if the
+	// root is nil. If the key exists, its value is returned, otherwise the
+	// value is zero.
+	GenerateProof(ctx context.Context, k, root *big.Int) (*MerkleTreeProof,
+		*big.Int, error)
+}
+
+// MerkleTreeProof is the proof of existence or non-existence of the key in
+// MerkleTree
+type MerkleTreeProof struct {
+	// Existence is true if the key is in the tree
+	Existence bool
+	// Siblings are the siblings from the root down to the leaf, including
+	// empty (zero) siblings. The number of siblings is the depth of the leaf.
+	Siblings []*big.Int
+	// NodeAux is the leaf that is found on the path of the key in the proof
+	// of non-existence. It is nil if the path ends in the empty node.
+	NodeAux *MerkleTreeNodeAux
+}
+
+// MerkleTreeNodeAux is the auxiliary leaf of the proof of non-existence
+type MerkleTreeNodeAux struct {
+	Key   *big.Int
+	Value *big.Int
+}
+
+// CircuitSiblings returns the siblings padded with zeros to the number of
+// levels of the circuit
+func (p *MerkleTreeProof) CircuitSiblings(levels int) ([]*big.Int, error) {
+	if len(p.Siblings) > levels {
+		return nil, fmt.Errorf("proof has %v siblings, more than %v levels",
+			len(p.Siblings), levels)
+	}
+	siblings := make([]*big.Int, levels)
+	for i := range siblings {
+		if i < len(p.Siblings) {
+			siblings[i] = new(big.Int).Set(p.Siblings[i])
+		} else {
+			siblings[i] = big.NewInt(0)
+		}
+	}
+	return siblings, nil
+}
+
+// VerifyMerkleTreeProof verifies the proof of the entry against the root of
+// the tree. For the proof of non-existence the value is ignored.
+func VerifyMerkleTreeProof(root *big.Int, p *MerkleTreeProof,
+	k, v *big.Int) bool {
+
+	if root == nil || p == nil {
+		return false
+	}
+	if !p.Existence {
+		v = big.NewInt(0)
+	}
+	calcRoot, err := rootFromProof(p, k, v)
+	if err != nil {
+		return false
+	}
+	return calcRoot.Cmp(root) == 0
+}
+
+// rootFromProof calculates the root of the tree from the proof of the entry
+func rootFromProof(p *MerkleTreeProof, k, v *big.Int) (*big.Int, error) {
+	err := checkFieldElements(k, v)
+	if err != nil {
+		return nil, err
+	}
+	err = checkFieldElements(p.Siblings...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sibling: %w", err)
+	}
+
+	var (
+		h   *big.Int
+		lvl = len(p.Siblings) - 1
+	)
+	switch {
+	case p.Existence:
+		h, err = poseidon.Hash([]*big.Int{k, v, big.NewInt(1)})
+	case p.NodeAux == nil:
+		h = big.NewInt(0)
+	default:
+		aux := p.NodeAux
+		if checkFieldElements(aux.Key, aux.Value) != nil ||
+			aux.Key.Cmp(k) == 0 {
+
+			return nil, errors.New(
+				"invalid proof of non-existence: invalid auxiliary node")
+		}
+		// the auxiliary leaf must be on the path of the key
+		for i := 0; i <= lvl; i++ {
+			if aux.Key.Bit(i) != k.Bit(i) {
+				return nil, errors.New("invalid proof of non-existence: " +
+					"auxiliary node is not on the path of the key")
+			}
+		}
+		h, err = poseidon.Hash([]*big.Int{aux.Key, aux.Value, big.NewInt(1)})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for ; lvl >= 0; lvl-- {
+		sibling := p.Siblings[lvl]
+		if k.Bit(lvl) == 1 {
+			h, err = poseidon.Hash([]*big.Int{sibling, h})
+		} else {
+			h, err = poseidon.Hash([]*big.Int{h, sibling})
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// checkFieldElements returns an error if any value is nil or is not an
+// element of the Poseidon field
+func checkFieldElements(values ...*big.Int) error {
+	for _, v := range values {
+		if v == nil || v.Sign() < 0 || !utils.CheckBigIntInField(v) {
+			return errors.New("value is not inside the finite field")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/stretchr/testify/require"
+)
+
+// memMerkleTree is minimal in-memory MerkleTree for tests. Nodes are never
+// removed, so proofs may be generated for any previous root.
+type memMerkleTree struct {
+	mu        sync.RWMutex
+	nodes     map[string]memMerkleTreeNode
+	root      *big.Int
+	maxLevels int
+}
+
+// memMerkleTreeNode is middle node if key is nil, leaf node otherwise
+type memMerkleTreeNode struct {
+	left, right *big.Int
+	key, value  *big.Int
+}
+
+func newMemMerkleTree(maxLevels int) *memMerkleTree {
+	return &memMerkleTree{nodes: make(map[string]memMerkleTreeNode),
+		root: big.NewInt(0), maxLevels: maxLevels}
+}
+
+func testIdentityTrees() IdentityTrees {
+	return IdentityTrees{
+		ClaimsTree:     newMemMerkleTree(IdentityTreeLevels),
+		RevocationTree: newMemMerkleTree(IdentityTreeLevels),
+		RootsTree:      newMemMerkleTree(IdentityTreeLevels),
+	}
+}
+
+func (mt *memMerkleTree) Root() *big.Int {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+	return new(big.Int).Set(mt.root)
+}
+
+func (mt *memMerkleTree) Add(_ context.Context, k, v *big.Int) error {
+	return mt.set(k, v, false)
+}
+
+func (mt *memMerkleTree) Update(_ context.Context, k, v *big.Int) error {
+	return mt.set(k, v, true)
+}
+
+func (mt *memMerkleTree) set(k, v *big.Int, update bool) error {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+
+	leaf := memMerkleTreeNode{key: new(big.Int).Set(k),
+		value: new(big.Int).Set(v)}
+	root, err := mt.setLeaf(leaf, mt.root, 0, update)
+	if err != nil {
+		return err
+	}
+	mt.root = root
+	return nil
+}
+
+func (mt *memMerkleTree) setLeaf(leaf memMerkleTreeNode, h *big.Int, lvl int,
+	update bool) (*big.Int, error) {
+
+	if lvl >= mt.maxLevels {
+		return nil, errors.New("reached maximum level of the tree")
+	}
+	if h.Sign() == 0 {
+		if update {
+			return nil, errors.New("key not found")
+		}
+		return mt.addNode(leaf)
+	}
+
+	n := mt.nodes[h.String()]
+	if n.key != nil {
+		switch {
+		case n.key.Cmp(leaf.key) == 0 && update:
+			return mt.addNode(leaf)
+		case n.key.Cmp(leaf.key) == 0:
+			return nil, errors.New("key already exists")
+		case update:
+			return nil, errors.New("key not found")
+		}
+		// push the old leaf down under the new middle node
+		oldKey := n.key
+		n = memMerkleTreeNode{left: h, right: big.NewInt(0)}
+		if oldKey.Bit(lvl) == 1 {
+			n.left, n.right = n.right, n.left
+		}
+	}
+
+	var err error
+	if leaf.key.Bit(lvl) == 1 {
+		n.right, err = mt.setLeaf(leaf, n.right, lvl+1, update)
+	} else {
+		n.left, err = mt.setLeaf(leaf, n.left, lvl+1, update)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mt.addNode(n)
+}
+
+func (mt *memMerkleTree) addNode(n memMerkleTreeNode) (*big.Int, error) {
+	var (
+		h   *big.Int
+		err error
+	)
+	if n.key != nil {
+		h, err = poseidon.Hash([]*big.Int{n.key, n.value, big.NewInt(1)})
+	} else {
+		h, err = poseidon.Hash([]*big.Int{n.left, n.right})
+	}
+	if err != nil {
+		return nil, err
+	}
+	mt.nodes[h.String()] = n
+	return h, nil
+}
+
+func (mt *memMerkleTree) GenerateProof(_ context.Context, k,
+	root *big.Int) (*MerkleTreeProof, *big.Int, error) {
+
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+
+	if root == nil {
+		root = mt.root
+	}
+	if _, ok := mt.nodes[root.String()]; !ok && root.Sign() != 0 {
+		return nil, nil, errors.New("root not found")
+	}
+
+	p := &MerkleTreeProof{}
+	for h, lvl := root, 0; h.Sign() != 0; lvl++ {
+		n := mt.nodes[h.String()]
+		if n.key != nil {
+			if n.key.Cmp(k) == 0 {
+				p.Existence = true
+				return p, new(big.Int).Set(n.value), nil
+			}
+			p.NodeAux = &MerkleTreeNodeAux{Key: new(big.Int).Set(n.key),
+				Value: new(big.Int).Set(n.value)}
+			break
+		}
+		if k.Bit(lvl) == 1 {
+			h = n.right
+			p.Siblings = append(p.Siblings, new(big.Int).Set(n.left))
+		} else {
+			h = n.left
+			p.Siblings = append(p.Siblings, new(big.Int).Set(n.right))
+		}
+	}
+	return p, big.NewInt(0), nil
+}
+
+// Root vectors of github.com/iden3/go-merkletree-sql TestNewTree
+func TestMemMerkleTree_Vectors(t *testing.T) {
+	ctx := context.Background()
+	mt := newMemMerkleTree(10)
+	require.Equal(t, "0", mt.Root().String())
+
+	require.NoError(t, mt.Add(ctx, big.NewInt(1), big.NewInt(2)))
+	require.Equal(t,
+		"13578938674299138072471463694055224830892726234048532520316387704878000008795",
+		mt.Root().String())
+
+	require.NoError(t, mt.Add(ctx, big.NewInt(33), big.NewInt(44)))
+	require.Equal(t,
+		"5412393676474193513566895793055462193090331607895808993925969873307089394741",
+		mt.Root().String())
+
+	require.NoError(t, mt.Add(ctx, big.NewInt(1234), big.NewInt(9876)))
+	require.Equal(t,
+		"14204494359367183802864593755198662203838502594566452929175967972147978322084",
+		mt.Root().String())
+
+	require.Error(t, mt.Add(ctx, big.NewInt(33), big.NewInt(45)))
+	require.Error(t, mt.Update(ctx, big.NewInt(35), big.NewInt(45)))
+}
+
+func TestVerifyMerkleTreeProof(t *testing.T) {
+	ctx := context.Background()
+	mt := newMemMerkleTree(40)
+	for i := int64(0); i < 10; i++ {
+		require.NoError(t, mt.Add(ctx, big.NewInt(i), big.NewInt(i*3)))
+	}
+	root := mt.Root()
+
+	for _, k := range []int64{3, 15, 16} {
+		p, v, err := mt.GenerateProof(ctx, big.NewInt(k), nil)
+		require.NoError(t, err)
+		require.Equal(t, k == 3, p.Existence)
+		require.True(t, VerifyMerkleTreeProof(root, p, big.NewInt(k), v))
+	}
+
+	p, _, err := mt.GenerateProof(ctx, big.NewInt(15), nil)
+	require.NoError(t, err)
+	// 15 = 0b1111 ends in the leaf 7 = 0b0111 at the level 3
+	require.Len(t, p.Siblings, 3)
+	require.Equal(t, &MerkleTreeNodeAux{Key: big.NewInt(7),
+		Value: big.NewInt(21)}, p.NodeAux)
+
+	siblings, err := p.CircuitSiblings(5)
+	require.NoError(t, err)
+	require.Equal(t, append(p.Siblings, big.NewInt(0), big.NewInt(0)),
+		siblings)
+	_, err = p.CircuitSiblings(2)
+	require.Error(t, err)
+
+	// the auxiliary node is not on the path of the key
+	require.False(t, VerifyMerkleTreeProof(root, p, big.NewInt(14), nil))
+	// proof of non-existence of the existing key
+	require.False(t, VerifyMerkleTreeProof(root, p, big.NewInt(7), nil))
+
+	p.Siblings[0] = big.NewInt(1)
+	require.False(t, VerifyMerkleTreeProof(root, p, big.NewInt(15), nil))
+
+	// siblings and auxiliary nodes of the proof decoded from untrusted
+	// input are validated
+	for _, sibling := range []*big.Int{nil, big.NewInt(-1), constants.Q} {
+		p, v, err := mt.GenerateProof(ctx, big.NewInt(3), nil)
+		require.NoError(t, err)
+		p.Siblings[0] = sibling
+		require.False(t, VerifyMerkleTreeProof(root, p, big.NewInt(3), v))
+	}
+	require.False(t, VerifyMerkleTreeProof(root, &MerkleTreeProof{
+		Existence: true, Siblings: []*big.Int{nil}}, big.NewInt(3),
+		big.NewInt(9)))
+	require.False(t, VerifyMerkleTreeProof(root, &MerkleTreeProof{
+		NodeAux: &MerkleTreeNodeAux{Key: big.NewInt(1)}}, big.NewInt(3), nil))
+	require.False(t, VerifyMerkleTreeProof(nil, p, big.NewInt(15), nil))
+	require.False(t, VerifyMerkleTreeProof(root, nil, big.NewInt(15), nil))
+}
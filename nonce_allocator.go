@@ -0,0 +1,338 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// nonceDrawAttempts is the number of random nonces that are drawn before
+// Reserve gives up. Collisions of 64-bit random nonces are unlikely, so
+// running out of attempts means the random source is broken.
+const nonceDrawAttempts = 32
+
+var (
+	// ErrNonceNotReserved returns when the nonce to commit or roll back is
+	// not reserved
+	ErrNonceNotReserved = errors.New("revocation nonce is not reserved")
+	// ErrNonceUsed returns when the nonce is already used by the issuer
+	ErrNonceUsed = errors.New("revocation nonce is already used")
+	// ErrNonceFileLocked returns when the nonces file is already used by
+	// another FileNonceAllocator
+	ErrNonceFileLocked = errors.New("nonces file is locked")
+
+	errFileLocked = errors.New("file is locked")
+)
+
+// NonceAllocator allocates unique random revocation nonces of the issuer
+// claims. The nonce is reserved before the claim is issued, and is
+// committed as used when the claim is issued or rolled back otherwise.
+// Implementations must be safe for concurrent use.
+type NonceAllocator interface {
+	// Reserve draws cryptographically random nonce that is neither used
+	// nor reserved by the issuer and reserves it
+	Reserve(ctx context.Context, issuer ID) (uint64, error)
+	// Commit marks the reserved nonce as used. Returns ErrNonceNotReserved
+	// if the nonce is not reserved.
+	Commit(ctx context.Context, issuer ID, nonce uint64) error
+	// Rollback releases the reserved nonce. Returns ErrNonceNotReserved if
+	// the nonce is not reserved.
+	Rollback(ctx context.Context, issuer ID, nonce uint64) error
+	// MarkUsed marks the nonce as used without reservation, e.g. the nonce
+	// of the claim that was issued before. Returns ErrNonceUsed if the
+	// nonce is already used.
+	MarkUsed(ctx context.Context, issuer ID, nonce uint64) error
+	// IsUsed returns true if the nonce is used by the issuer
+	IsUsed(ctx context.Context, issuer ID, nonce uint64) (bool, error)
+}
+
+type nonceSet map[uint64]struct{}
+
+// NonceAllocatorOption is an option of NewMemNonceAllocator and
+// NewFileNonceAllocator
+type NonceAllocatorOption func(*nonceAllocator)
+
+// WithNonceRandReader sets the source of random nonces. crypto/rand.Reader
+// is used by default.
+func WithNonceRandReader(r io.Reader) NonceAllocatorOption {
+	return func(a *nonceAllocator) {
+		a.rand = r
+	}
+}
+
+type nonceAllocator struct {
+	mu       sync.Mutex
+	rand     io.Reader
+	used     map[ID]nonceSet
+	reserved map[ID]nonceSet
+	// save persists the used nonce of the issuer, it is called with the
+	// lock held before the nonce is added to used nonces
+	save func(issuer ID, nonce uint64) error
+}
+
+func (a *nonceAllocator) init(opts []NonceAllocatorOption) {
+	a.rand = rand.Reader
+	a.used = make(map[ID]nonceSet)
+	a.reserved = make(map[ID]nonceSet)
+	a.save = func(ID, uint64) error { return nil }
+	for _, opt := range opts {
+		opt(a)
+	}
+}
+
+// Reserve draws random nonce that is neither used nor reserved by the
+// issuer and reserves it
+func (a *nonceAllocator) Reserve(_ context.Context, issuer ID) (uint64,
+	error) {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var buf [8]byte
+	for i := 0; i < nonceDrawAttempts; i++ {
+		_, err := io.ReadFull(a.rand, buf[:])
+		if err != nil {
+			return 0, fmt.Errorf("can't draw random nonce: %w", err)
+		}
+		nonce := binary.BigEndian.Uint64(buf[:])
+		if a.isUsed(issuer, nonce) || a.isReserved(issuer, nonce) {
+			continue
+		}
+		addNonce(a.reserved, issuer, nonce)
+		return nonce, nil
+	}
+	return 0, errors.New("can't draw unique random nonce")
+}
+
+// Commit marks the reserved nonce as used
+func (a *nonceAllocator) Commit(_ context.Context, issuer ID,
+	nonce uint64) error {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.isReserved(issuer, nonce) {
+		return ErrNonceNotReserved
+	}
+	err := a.markUsed(issuer, nonce)
+	if err != nil {
+		return err
+	}
+	deleteNonce(a.reserved, issuer, nonce)
+	return nil
+}
+
+// Rollback releases the reserved nonce
+func (a *nonceAllocator) Rollback(_ context.Context, issuer ID,
+	nonce uint64) error {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.isReserved(issuer, nonce) {
+		return ErrNonceNotReserved
+	}
+	deleteNonce(a.reserved, issuer, nonce)
+	return nil
+}
+
+// MarkUsed marks the nonce as used without reservation
+func (a *nonceAllocator) MarkUsed(_ context.Context, issuer ID,
+	nonce uint64) error {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.isUsed(issuer, nonce) {
+		return ErrNonceUsed
+	}
+	if a.isReserved(issuer, nonce) {
+		return fmt.Errorf("%w: nonce is reserved", ErrNonceUsed)
+	}
+	return a.markUsed(issuer, nonce)
+}
+
+// IsUsed returns true if the nonce is used by the issuer
+func (a *nonceAllocator) IsUsed(_ context.Context, issuer ID,
+	nonce uint64) (bool, error) {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.isUsed(issuer, nonce), nil
+}
+
+// markUsed saves the nonce and adds it to used nonces. The nonce is not
+// added if saving fails.
+func (a *nonceAllocator) markUsed(issuer ID, nonce uint64) error {
+	err := a.save(issuer, nonce)
+	if err != nil {
+		return err
+	}
+	addNonce(a.used, issuer, nonce)
+	return nil
+}
+
+func (a *nonceAllocator) isUsed(issuer ID, nonce uint64) bool {
+	_, ok := a.used[issuer][nonce]
+	return ok
+}
+
+func (a *nonceAllocator) isReserved(issuer ID, nonce uint64) bool {
+	_, ok := a.reserved[issuer][nonce]
+	return ok
+}
+
+func addNonce(m map[ID]nonceSet, issuer ID, nonce uint64) {
+	s, ok := m[issuer]
+	if !ok {
+		s = make(nonceSet)
+		m[issuer] = s
+	}
+	s[nonce] = struct{}{}
+}
+
+func deleteNonce(m map[ID]nonceSet, issuer ID, nonce uint64) {
+	delete(m[issuer], nonce)
+	if len(m[issuer]) == 0 {
+		delete(m, issuer)
+	}
+}
+
+// MemNonceAllocator is in-memory NonceAllocator
+type MemNonceAllocator struct {
+	nonceAllocator
+}
+
+// NewMemNonceAllocator creates new MemNonceAllocator without used nonces
+func NewMemNonceAllocator(opts ...NonceAllocatorOption) *MemNonceAllocator {
+	a := &MemNonceAllocator{}
+	a.init(opts)
+	return a
+}
+
+// FileNonceAllocator is NonceAllocator that keeps used nonces in
+// append-only log file. Every used nonce appends the line "<issuer> <nonce>"
+// to the file, the log is replayed when the allocator is created.
+// Reservations are kept in memory only. The allocator holds exclusive lock of
+// the file until it is closed, so the file can't be shared by several
+// allocators in this or other processes.
+type FileNonceAllocator struct {
+	nonceAllocator
+	file *os.File
+}
+
+// NewFileNonceAllocator creates new FileNonceAllocator. Used nonces are
+// loaded from the file if it exists. Returns ErrNonceFileLocked if the file
+// is used by another allocator.
+func NewFileNonceAllocator(path string,
+	opts ...NonceAllocatorOption) (*FileNonceAllocator, error) {
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("can't open nonces file: %w", err)
+	}
+	err = lockFile(f)
+	if errors.Is(err, errFileLocked) {
+		err = fmt.Errorf("%w: %v", ErrNonceFileLocked, path)
+	} else if err != nil {
+		err = fmt.Errorf("can't lock nonces file: %w", err)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	a := &FileNonceAllocator{file: f}
+	a.init(opts)
+	a.save = a.appendFile
+
+	err = a.loadFile()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+// Close releases the lock of the file. The nonces can't be committed or
+// marked used after Close.
+func (a *FileNonceAllocator) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
+
+// loadFile replays the log. The last line without trailing newline is the
+// record that was partially written and not committed, it is cut off so the
+// next record starts on its own line.
+func (a *FileNonceAllocator) loadFile() error {
+	data, err := io.ReadAll(a.file)
+	if err != nil {
+		return fmt.Errorf("can't read nonces file: %w", err)
+	}
+
+	end := bytes.LastIndexByte(data, '\n') + 1
+	if end < len(data) {
+		err = a.file.Truncate(int64(end))
+		if err != nil {
+			return fmt.Errorf("can't truncate nonces file: %w", err)
+		}
+	}
+
+	for i, line := range strings.Split(string(data[:end]), "\n") {
+		if line == "" {
+			continue
+		}
+		issuer, nonce, err := parseNonceRecord(line)
+		if err != nil {
+			return fmt.Errorf("invalid nonces file: line %v: %w", i+1, err)
+		}
+		addNonce(a.used, issuer, nonce)
+	}
+	return nil
+}
+
+func parseNonceRecord(line string) (ID, uint64, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return ID{}, 0, errors.New("invalid number of fields")
+	}
+	issuer, err := IDFromString(fields[0])
+	if err != nil {
+		return ID{}, 0, err
+	}
+	nonce, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return ID{}, 0, err
+	}
+	return issuer, nonce, nil
+}
+
+// appendFile appends the record of the used nonce to the file and syncs it.
+// The partially written record is truncated, so the file holds only complete
+// records.
+func (a *FileNonceAllocator) appendFile(issuer ID, nonce uint64) error {
+	info, err := a.file.Stat()
+	if err == nil {
+		record := issuer.String() + " " + strconv.FormatUint(nonce, 10) + "\n"
+		_, err = a.file.WriteString(record)
+		if err == nil {
+			err = a.file.Sync()
+		}
+		if err != nil {
+			_ = a.file.Truncate(info.Size())
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("can't save nonces file: %w", err)
+	}
+	return nil
+}
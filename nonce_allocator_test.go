@@ -0,0 +1,251 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testNonceReader returns reader of big-endian nonces
+func testNonceReader(nonces ...uint64) *bytes.Reader {
+	buf := make([]byte, 8*len(nonces))
+	for i, n := range nonces {
+		binary.BigEndian.PutUint64(buf[8*i:], n)
+	}
+	return bytes.NewReader(buf)
+}
+
+func testNonceAllocator(t *testing.T, a NonceAllocator) {
+	ctx := context.Background()
+	issuer := testGistID(t, 1)
+	otherIssuer := testGistID(t, 2)
+
+	n1, err := a.Reserve(ctx, issuer)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), n1)
+
+	// 1 is reserved, 2 is drawn
+	n2, err := a.Reserve(ctx, issuer)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), n2)
+
+	// the same nonce may be used by the other issuer
+	n, err := a.Reserve(ctx, otherIssuer)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), n)
+
+	require.NoError(t, a.Commit(ctx, issuer, n1))
+	used, err := a.IsUsed(ctx, issuer, n1)
+	require.NoError(t, err)
+	require.True(t, used)
+	used, err = a.IsUsed(ctx, otherIssuer, n1)
+	require.NoError(t, err)
+	require.False(t, used)
+
+	require.NoError(t, a.Rollback(ctx, issuer, n2))
+	used, err = a.IsUsed(ctx, issuer, n2)
+	require.NoError(t, err)
+	require.False(t, used)
+
+	require.ErrorIs(t, a.Commit(ctx, issuer, n1), ErrNonceNotReserved)
+	require.ErrorIs(t, a.Commit(ctx, issuer, n2), ErrNonceNotReserved)
+	require.ErrorIs(t, a.Rollback(ctx, issuer, n2), ErrNonceNotReserved)
+
+	require.NoError(t, a.MarkUsed(ctx, issuer, 3))
+	require.ErrorIs(t, a.MarkUsed(ctx, issuer, 3), ErrNonceUsed)
+	require.ErrorIs(t, a.MarkUsed(ctx, issuer, n1), ErrNonceUsed)
+	require.ErrorIs(t, a.MarkUsed(ctx, otherIssuer, 1), ErrNonceUsed)
+
+	// 1 and 3 are used, 2 is rolled back and drawn again
+	n, err = a.Reserve(ctx, issuer)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), n)
+
+	// the random source is exhausted
+	_, err = a.Reserve(ctx, issuer)
+	require.Error(t, err)
+}
+
+func TestMemNonceAllocator(t *testing.T) {
+	a := NewMemNonceAllocator(WithNonceRandReader(
+		testNonceReader(1, 1, 2, 1, 1, 3, 2)))
+	testNonceAllocator(t, a)
+}
+
+func TestFileNonceAllocator(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "nonces.log")
+
+	a, err := NewFileNonceAllocator(path, WithNonceRandReader(
+		testNonceReader(1, 1, 2, 1, 1, 3, 2)))
+	require.NoError(t, err)
+	testNonceAllocator(t, a)
+	require.NoError(t, a.Close())
+
+	// used nonces are loaded, reservations are not kept
+	a, err = NewFileNonceAllocator(path, WithNonceRandReader(
+		testNonceReader(1, 2)))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, a.Close()) }()
+
+	issuer := testGistID(t, 1)
+	for _, n := range []uint64{1, 3} {
+		used, err := a.IsUsed(ctx, issuer, n)
+		require.NoError(t, err)
+		require.True(t, used)
+	}
+	used, err := a.IsUsed(ctx, issuer, 2)
+	require.NoError(t, err)
+	require.False(t, used)
+	used, err = a.IsUsed(ctx, testGistID(t, 2), 1)
+	require.NoError(t, err)
+	require.False(t, used)
+
+	n, err := a.Reserve(ctx, issuer)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), n)
+
+	// every used nonce is appended to the log
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, issuer.String()+" 1\n"+issuer.String()+" 3\n",
+		string(data))
+}
+
+func TestFileNonceAllocator_PartialRecord(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "nonces.log")
+	issuer := testGistID(t, 1)
+
+	// the last record was not completely written
+	require.NoError(t, os.WriteFile(path,
+		[]byte(issuer.String()+" 1\n"+issuer.String()+" 2"), 0o600))
+
+	a, err := NewFileNonceAllocator(path)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, a.Close()) }()
+	used, err := a.IsUsed(ctx, issuer, 1)
+	require.NoError(t, err)
+	require.True(t, used)
+	used, err = a.IsUsed(ctx, issuer, 2)
+	require.NoError(t, err)
+	require.False(t, used)
+
+	require.NoError(t, a.MarkUsed(ctx, issuer, 3))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, issuer.String()+" 1\n"+issuer.String()+" 3\n",
+		string(data))
+}
+
+func TestFileNonceAllocator_Locked(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "nonces.log")
+	issuer := testGistID(t, 1)
+
+	a, err := NewFileNonceAllocator(path)
+	require.NoError(t, err)
+
+	// the second allocator would not see the nonces used by the first one
+	_, err = NewFileNonceAllocator(path)
+	require.ErrorIs(t, err, ErrNonceFileLocked)
+
+	require.NoError(t, a.MarkUsed(ctx, issuer, 1))
+	require.NoError(t, a.Close())
+
+	a, err = NewFileNonceAllocator(path)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, a.Close()) }()
+	require.ErrorIs(t, a.MarkUsed(ctx, issuer, 1), ErrNonceUsed)
+}
+
+func TestFileNonceAllocator_Errors(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	issuer := testGistID(t, 1)
+	path := filepath.Join(dir, "invalid.log")
+	for _, line := range []string{"x 1", issuer.String() + " x",
+		issuer.String()} {
+
+		require.NoError(t, os.WriteFile(path, []byte(line+"\n"), 0o600))
+		_, err := NewFileNonceAllocator(path)
+		require.Error(t, err, line)
+	}
+
+	// the invalid file is not kept locked
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+	a, err := NewFileNonceAllocator(path)
+	require.NoError(t, err)
+	require.NoError(t, a.Close())
+
+	_, err = NewFileNonceAllocator(filepath.Join(dir, "missing", "nonces.log"))
+	require.Error(t, err)
+
+	// the nonce stays reserved if it can't be saved
+	path = filepath.Join(dir, "nonces.log")
+	a, err = NewFileNonceAllocator(path, WithNonceRandReader(
+		testNonceReader(1)))
+	require.NoError(t, err)
+	n, err := a.Reserve(ctx, issuer)
+	require.NoError(t, err)
+	require.NoError(t, a.Close())
+	require.Error(t, a.Commit(ctx, issuer, n))
+	used, err := a.IsUsed(ctx, issuer, n)
+	require.NoError(t, err)
+	require.False(t, used)
+	require.NoError(t, a.Rollback(ctx, issuer, n))
+}
+
+func TestMemNonceAllocator_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	a := NewMemNonceAllocator()
+	issuer := testGistID(t, 1)
+
+	// goroutines send the committed nonces and errors, they are checked in
+	// the test goroutine
+	var wg sync.WaitGroup
+	committed := make(chan uint64, 16*25)
+	errs := make(chan error, 16*50)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				n, err := a.Reserve(ctx, issuer)
+				if err != nil {
+					errs <- err
+					continue
+				}
+				if j%2 == 0 {
+					errs <- a.Rollback(ctx, issuer, n)
+					continue
+				}
+				err = a.Commit(ctx, issuer, n)
+				if err == nil {
+					committed <- n
+				}
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(committed)
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	nonces := make(map[uint64]bool)
+	for n := range committed {
+		require.False(t, nonces[n])
+		nonces[n] = true
+	}
+	require.Len(t, nonces, 16*25)
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+)
+
+// ErrInvalidProfileNonceInput returns when the seed or the domain to derive
+// profile nonce is empty.
+var ErrInvalidProfileNonceInput = errors.New(
+	"invalid input for profile nonce derivation")
+
+// ProfileDID calculates the Profile DID from the Identity DID and profile
+// nonce. Method, blockchain and network of the identity are preserved. If
+// nonce is empty or zero, the identity DID is returned. Params, path, query
+// and fragment of did are dropped. Profiles of did:pkh and did:ethr are not
+// supported, ErrMethodUnknown is returned for them.
+func ProfileDID(did w3c.DID, nonce *big.Int) (*w3c.DID, error) {
+	id, err := profileIdentityID(did)
+	if err != nil {
+		return nil, err
+	}
+
+	profileID, err := ProfileID(id, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseDIDFromID(profileID)
+}
+
+// VerifyProfile returns true if profileDID is the profile of identityDID
+// with the nonce.
+func VerifyProfile(identityDID, profileDID w3c.DID, nonce *big.Int) bool {
+	profileID, err := profileIdentityID(profileDID)
+	if err != nil {
+		return false
+	}
+
+	identityID, err := profileIdentityID(identityDID)
+	if err != nil {
+		return false
+	}
+
+	wantProfileID, err := ProfileID(identityID, nonce)
+	if err != nil {
+		return false
+	}
+
+	return wantProfileID.Equal(&profileID)
+}
+
+// profileIdentityID returns ID of the DID that may have profiles. did:pkh and
+// did:ethr are mapped to iden3 IDs, the profile of such ID is not Ethereum
+// controlled and can't be presented as DID of the same method.
+func profileIdentityID(did w3c.DID) (ID, error) {
+	method := DIDMethod(did.Method)
+	if method == DIDMethodPKH || method == DIDMethodEthr {
+		return ID{}, fmt.Errorf("%w: profiles of did:%v are not supported",
+			ErrMethodUnknown, method)
+	}
+	return idFromDID(did)
+}
+
+// ProfileNonceFromDomain derives the profile nonce for the verifier domain.
+// The same seed and domain always give the same nonce, so the wallet needs
+// to keep only the seed to get a stable profile for each verifier.
+//
+// The seed must be secret. If the nonce depended on the domain only, anyone
+// who knows the identity could calculate its profile for the domain and link
+// them.
+//
+// The domain is case-insensitive, trailing dot is ignored. The nonce is
+//
+//	Poseidon(HashBytes(seed), HashBytes(domain))
+func ProfileNonceFromDomain(seed []byte, domain string) (*big.Int, error) {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	if len(seed) == 0 {
+		return nil, fmt.Errorf("%w: seed is empty",
+			ErrInvalidProfileNonceInput)
+	}
+	if domain == "" {
+		return nil, fmt.Errorf("%w: domain is empty",
+			ErrInvalidProfileNonceInput)
+	}
+
+	seedHash, err := poseidon.HashBytes(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	domainHash, err := poseidon.HashBytes([]byte(domain))
+	if err != nil {
+		return nil, err
+	}
+
+	return poseidon.Hash([]*big.Int{seedHash, domainHash})
+}
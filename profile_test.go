@@ -0,0 +1,110 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileDID(t *testing.T) {
+	did, err := w3c.ParseDID(
+		"did:polygonid:polygon:mumbai:2qEw5HAQFBh6aavAufixuUXY6J9ip4MiuH3yJbAuer")
+	require.NoError(t, err)
+	id, err := IDFromDID(*did)
+	require.NoError(t, err)
+
+	nonce := big.NewInt(10)
+	profileDID, err := ProfileDID(*did, nonce)
+	require.NoError(t, err)
+
+	// method, blockchain and network are preserved
+	require.Equal(t, "polygonid", profileDID.Method)
+	require.Equal(t, []string{"polygon", "mumbai"}, profileDID.IDStrings[:2])
+
+	profileID, err := IDFromDID(*profileDID)
+	require.NoError(t, err)
+	wantProfileID, err := ProfileID(id, nonce)
+	require.NoError(t, err)
+	require.Equal(t, wantProfileID, profileID)
+
+	require.True(t, VerifyProfile(*did, *profileDID, nonce))
+	require.False(t, VerifyProfile(*did, *profileDID, big.NewInt(11)))
+	require.False(t, VerifyProfile(*profileDID, *did, nonce))
+
+	// empty nonce gives the identity DID
+	sameDID, err := ProfileDID(*did, nil)
+	require.NoError(t, err)
+	require.Equal(t, did.String(), sameDID.String())
+	require.True(t, VerifyProfile(*did, *did, nil))
+
+	// DID URL parts are dropped
+	didURL := *did
+	didURL.Fragment = "key-1"
+	profileDID2, err := ProfileDID(didURL, nonce)
+	require.NoError(t, err)
+	require.Equal(t, profileDID.String(), profileDID2.String())
+}
+
+func TestProfileDID_UnsupportedDID(t *testing.T) {
+	did, err := w3c.ParseDID("did:example:123")
+	require.NoError(t, err)
+
+	_, err = ProfileDID(*did, big.NewInt(10))
+	require.True(t, errors.Is(err, ErrMethodUnknown), err)
+	require.False(t, VerifyProfile(*did, *did, big.NewInt(10)))
+}
+
+func TestProfileDID_EthDID(t *testing.T) {
+	// profiles of DIDs mapped to Ethereum-controlled IDs would change the
+	// method to iden3
+	for _, s := range []string{
+		"did:pkh:eip155:137:" + testEthAddress,
+		"did:ethr:polygon:" + testEthAddress,
+	} {
+		did, err := w3c.ParseDID(s)
+		require.NoError(t, err)
+
+		_, err = ProfileDID(*did, big.NewInt(5))
+		require.True(t, errors.Is(err, ErrMethodUnknown), err)
+		_, err = ProfileDID(*did, nil)
+		require.True(t, errors.Is(err, ErrMethodUnknown), err)
+		require.False(t, VerifyProfile(*did, *did, nil))
+
+		id, err := IDFromDID(*did)
+		require.NoError(t, err)
+		iden3DID, err := ParseDIDFromID(id)
+		require.NoError(t, err)
+		require.False(t, VerifyProfile(*did, *iden3DID, nil))
+	}
+}
+
+func TestProfileNonceFromDomain(t *testing.T) {
+	seed := []byte("wallet secret seed")
+
+	nonce, err := ProfileNonceFromDomain(seed, "verifier.example.com")
+	require.NoError(t, err)
+	require.Equal(t, 1, nonce.Sign())
+
+	// stable and case-insensitive
+	nonce2, err := ProfileNonceFromDomain(seed, "Verifier.Example.COM.")
+	require.NoError(t, err)
+	require.Equal(t, nonce, nonce2)
+
+	// different domains and seeds give different nonces
+	nonce3, err := ProfileNonceFromDomain(seed, "other.example.com")
+	require.NoError(t, err)
+	require.NotEqual(t, nonce, nonce3)
+
+	nonce4, err := ProfileNonceFromDomain([]byte("other seed"),
+		"verifier.example.com")
+	require.NoError(t, err)
+	require.NotEqual(t, nonce, nonce4)
+
+	_, err = ProfileNonceFromDomain(nil, "verifier.example.com")
+	require.True(t, errors.Is(err, ErrInvalidProfileNonceInput), err)
+	_, err = ProfileNonceFromDomain(seed, ".")
+	require.True(t, errors.Is(err, ErrInvalidProfileNonceInput), err)
+}
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+// ErrNonceRevoked returns when the revocation nonce is revoked
+var ErrNonceRevoked = errors.New("revocation nonce is revoked")
+
+// RevocationRegistry revokes claims by inserting their revocation nonces to
+// the revocation tree. The leaf of revoked nonce has zero value.
+// RevocationRegistry is safe for concurrent use, the tree must not be changed
+// by others.
+type RevocationRegistry struct {
+	mu   sync.RWMutex
+	tree MerkleTree
+}
+
+// NewRevocationRegistry creates RevocationRegistry on top of the revocation
+// tree, e.g. Identity.RevocationTree
+func NewRevocationRegistry(tree MerkleTree) *RevocationRegistry {
+	return &RevocationRegistry{tree: tree}
+}
+
+// Root returns the root of the revocation tree
+func (r *RevocationRegistry) Root() *big.Int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.tree.Root()
+}
+
+// Revoke revokes the nonce. Returns ErrNonceRevoked if the nonce is already
+// revoked.
+func (r *RevocationRegistry) Revoke(ctx context.Context, nonce uint64) error {
+	_, err := r.RevokeBatch(ctx, []uint64{nonce})
+	return err
+}
+
+// RevokeClaim revokes the revocation nonce of the claim
+func (r *RevocationRegistry) RevokeClaim(ctx context.Context, c *Claim) error {
+	if c == nil {
+		return errors.New("claim is nil")
+	}
+	return r.Revoke(ctx, c.GetRevocationNonce())
+}
+
+// RevokeBatch revokes the nonces and returns the resulting root of the
+// revocation tree. All nonces are validated before the first one is added,
+// so none of them is revoked if any nonce is already revoked or is repeated
+// (ErrNonceRevoked is returned). Only the storage failure of the tree may
+// leave the nonces before the failed one revoked.
+func (r *RevocationRegistry) RevokeBatch(ctx context.Context,
+	nonces []uint64) (*big.Int, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := make([]*big.Int, len(nonces))
+	seen := make(map[uint64]struct{}, len(nonces))
+	for i, nonce := range nonces {
+		keys[i] = new(big.Int).SetUint64(nonce)
+		err := checkFieldElements(keys[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid nonce %v: %w", nonce, err)
+		}
+
+		if _, ok := seen[nonce]; ok {
+			return nil, fmt.Errorf("%w: %v is repeated", ErrNonceRevoked,
+				nonce)
+		}
+		seen[nonce] = struct{}{}
+
+		revoked, err := r.isRevoked(ctx, nonce)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, fmt.Errorf("%w: %v", ErrNonceRevoked, nonce)
+		}
+	}
+
+	for i, k := range keys {
+		err := r.tree.Add(ctx, k, big.NewInt(0))
+		if err != nil {
+			return nil, fmt.Errorf("can't revoke nonce %v: %w", nonces[i], err)
+		}
+	}
+	return r.tree.Root(), nil
+}
+
+// IsRevoked returns true if the nonce is revoked
+func (r *RevocationRegistry) IsRevoked(ctx context.Context,
+	nonce uint64) (bool, error) {
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.isRevoked(ctx, nonce)
+}
+
+func (r *RevocationRegistry) isRevoked(ctx context.Context,
+	nonce uint64) (bool, error) {
+
+	proof, _, err := r.tree.GenerateProof(ctx, new(big.Int).SetUint64(nonce),
+		nil)
+	if err != nil {
+		return false, err
+	}
+	return proof.Existence, nil
+}
+
+// NonRevocationProof is the proof that the nonce is not in the revocation
+// tree of the root
+type NonRevocationProof struct {
+	Root  *big.Int
+	Nonce uint64
+	Proof *MerkleTreeProof
+}
+
+// Verify verifies the proof against its root
+func (p *NonRevocationProof) Verify() bool {
+	if p == nil || p.Proof == nil {
+		return false
+	}
+	return !p.Proof.Existence && VerifyMerkleTreeProof(p.Root, p.Proof,
+		new(big.Int).SetUint64(p.Nonce), nil)
+}
+
+// NonRevocationProof returns the proof of non-revocation of the nonce in
+// the current revocation tree. Returns ErrNonceRevoked if the nonce is
+// revoked.
+func (r *RevocationRegistry) NonRevocationProof(ctx context.Context,
+	nonce uint64) (*NonRevocationProof, error) {
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	root := r.tree.Root()
+	proof, _, err := r.tree.GenerateProof(ctx, new(big.Int).SetUint64(nonce),
+		root)
+	if err != nil {
+		return nil, err
+	}
+	if proof.Existence {
+		return nil, fmt.Errorf("%w: %v", ErrNonceRevoked, nonce)
+	}
+	return &NonRevocationProof{Root: root, Nonce: nonce, Proof: proof}, nil
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/stretchr/testify/require"
+)
+
+func testRevocationRegistry(t testing.TB) *RevocationRegistry {
+	t.Helper()
+	return NewRevocationRegistry(newMemMerkleTree(IdentityTreeLevels))
+}
+
+func TestRevocationRegistry(t *testing.T) {
+	ctx := context.Background()
+	r := testRevocationRegistry(t)
+	require.Equal(t, big.NewInt(0), r.Root())
+
+	revoked, err := r.IsRevoked(ctx, 10)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	p, err := r.NonRevocationProof(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(0), p.Root)
+	require.True(t, p.Verify())
+
+	require.NoError(t, r.Revoke(ctx, 10))
+	require.ErrorIs(t, r.Revoke(ctx, 10), ErrNonceRevoked)
+
+	claim, err := NewClaim(SchemaHash{1}, WithRevocationNonce(11))
+	require.NoError(t, err)
+	require.NoError(t, r.RevokeClaim(ctx, claim))
+
+	for _, nonce := range []uint64{10, 11} {
+		revoked, err = r.IsRevoked(ctx, nonce)
+		require.NoError(t, err)
+		require.True(t, revoked)
+
+		_, err = r.NonRevocationProof(ctx, nonce)
+		require.ErrorIs(t, err, ErrNonceRevoked)
+	}
+
+	// the leaf of the revoked nonce has zero value
+	leaf, err := poseidon.Hash([]*big.Int{big.NewInt(10), big.NewInt(0),
+		big.NewInt(1)})
+	require.NoError(t, err)
+	tree := newMemMerkleTree(IdentityTreeLevels)
+	require.NoError(t, tree.Add(ctx, big.NewInt(10), big.NewInt(0)))
+	require.Equal(t, leaf, tree.Root())
+	require.NoError(t, tree.Add(ctx, big.NewInt(11), big.NewInt(0)))
+	require.Equal(t, tree.Root(), r.Root())
+
+	for _, nonce := range []uint64{0, 12, 1 << 63} {
+		p, err = r.NonRevocationProof(ctx, nonce)
+		require.NoError(t, err)
+		require.Equal(t, r.Root(), p.Root)
+		require.Equal(t, nonce, p.Nonce)
+		require.True(t, p.Verify())
+	}
+
+	// the proof is not valid for the revoked nonce
+	p.Nonce = 10
+	require.False(t, p.Verify())
+}
+
+func TestRevocationRegistry_RevokeBatch(t *testing.T) {
+	ctx := context.Background()
+	r1 := testRevocationRegistry(t)
+	r2 := testRevocationRegistry(t)
+
+	nonces := []uint64{5, 1, 1 << 40, 7}
+	for _, nonce := range nonces {
+		require.NoError(t, r1.Revoke(ctx, nonce))
+	}
+	root, err := r2.RevokeBatch(ctx, nonces)
+	require.NoError(t, err)
+	require.Equal(t, r1.Root(), root)
+	require.Equal(t, r1.Root(), r2.Root())
+
+	// none of the nonces is revoked if any of them is revoked or repeated
+	_, err = r2.RevokeBatch(ctx, []uint64{100, 7})
+	require.ErrorIs(t, err, ErrNonceRevoked)
+	_, err = r2.RevokeBatch(ctx, []uint64{100, 101, 100})
+	require.ErrorIs(t, err, ErrNonceRevoked)
+	require.Equal(t, root, r2.Root())
+	revoked, err := r2.IsRevoked(ctx, 100)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	root, err = r2.RevokeBatch(ctx, nil)
+	require.NoError(t, err)
+	require.Equal(t, r1.Root(), root)
+}
+
+func TestRevocationRegistry_IdentityState(t *testing.T) {
+	ctx := context.Background()
+	k := testBJJKey(t)
+	identity, err := NewIdentityFromAuthKey(ctx, k.Public(),
+		DIDMethodPolygonID, Polygon, Mumbai, testIdentityTrees())
+	require.NoError(t, err)
+
+	r := NewRevocationRegistry(identity.RevocationTree)
+	claim, err := NewClaim(SchemaHash{1}, WithRevocationNonce(3))
+	require.NoError(t, err)
+	require.NoError(t, r.RevokeClaim(ctx, claim))
+
+	state, err := identity.State()
+	require.NoError(t, err)
+	wantState, err := IdenState(identity.ClaimsTree.Root(), r.Root(),
+		big.NewInt(0))
+	require.NoError(t, err)
+	require.Equal(t, wantState, state)
+	require.NotEqual(t, identity.GenesisState, state)
+}
+
+// failingAddTree is the tree that fails to add entries, e.g. on storage
+// failure
+type failingAddTree struct {
+	*memMerkleTree
+	adds int
+}
+
+func (t *failingAddTree) Add(_ context.Context, _, _ *big.Int) error {
+	t.adds++
+	return errors.New("storage failure")
+}
+
+func TestRevocationRegistry_Errors(t *testing.T) {
+	ctx := context.Background()
+	r := testRevocationRegistry(t)
+
+	require.Error(t, r.RevokeClaim(ctx, nil))
+	require.False(t, (*NonRevocationProof)(nil).Verify())
+	require.False(t, (&NonRevocationProof{Root: big.NewInt(0)}).Verify())
+
+	// nonces are validated before the tree is changed
+	tree := &failingAddTree{memMerkleTree: newMemMerkleTree(IdentityTreeLevels)}
+	require.NoError(t, tree.memMerkleTree.Add(ctx, big.NewInt(7),
+		big.NewInt(0)))
+	r = NewRevocationRegistry(tree)
+	_, err := r.RevokeBatch(ctx, []uint64{1, 7})
+	require.ErrorIs(t, err, ErrNonceRevoked)
+	_, err = r.RevokeBatch(ctx, []uint64{1, 2, 1})
+	require.ErrorIs(t, err, ErrNonceRevoked)
+	require.Equal(t, 0, tree.adds)
+
+	_, err = r.RevokeBatch(ctx, []uint64{1, 2})
+	require.EqualError(t, err, "can't revoke nonce 1: storage failure")
+	require.Equal(t, 1, tree.adds)
+}
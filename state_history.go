@@ -0,0 +1,197 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// ErrStateAlreadyExists returns when the state is already recorded for the
+// identity
+var ErrStateAlreadyExists = errors.New("identity state already exists")
+
+// StateRecord is the state of identity recorded in StateHistory
+type StateRecord struct {
+	ID    ID
+	State *big.Int
+	// Roots are the roots of identity trees of the state
+	Roots     TreeRoots
+	CreatedAt time.Time
+	// ReplacedByState is the next state of identity, nil if the state is
+	// current
+	ReplacedByState *big.Int
+	// ReplacedAt is the time when the state was replaced, zero if the state
+	// is current
+	ReplacedAt time.Time
+}
+
+// IsCurrent returns true if the state is not replaced
+func (r *StateRecord) IsCurrent() bool {
+	return r.ReplacedByState == nil
+}
+
+// StateHistory keeps the history of identity states
+type StateHistory interface {
+	// AddState records new state of identity with the roots of its trees.
+	// The latest state of identity is replaced at createdAt.
+	AddState(ctx context.Context, id ID, roots TreeRoots,
+		createdAt time.Time) (*StateRecord, error)
+	// LatestState returns the current state of identity. Returns
+	// ErrStateNotFound if identity has no states.
+	LatestState(ctx context.Context, id ID) (*StateRecord, error)
+	// StateAt returns the state of identity that was current at the time.
+	// Returns ErrStateNotFound if identity had no states at the time.
+	StateAt(ctx context.Context, id ID, t time.Time) (*StateRecord, error)
+	// State returns the record of the state of identity. Returns
+	// ErrStateNotFound if the state is not recorded.
+	State(ctx context.Context, id ID, state *big.Int) (*StateRecord, error)
+	// IsStateCurrent returns true if the state is the latest state of
+	// identity. Returns ErrStateNotFound if the state is not recorded.
+	IsStateCurrent(ctx context.Context, id ID, state *big.Int) (bool, error)
+}
+
+// MemStateHistory is in-memory StateHistory. It is safe for concurrent use.
+type MemStateHistory struct {
+	mu sync.RWMutex
+	// states of identity from the first to the latest one
+	states map[ID][]*StateRecord
+}
+
+// NewMemStateHistory creates new empty MemStateHistory
+func NewMemStateHistory() *MemStateHistory {
+	return &MemStateHistory{states: make(map[ID][]*StateRecord)}
+}
+
+// AddState records new state of identity with the roots of its trees. The
+// time must not be before the creation time of the latest state.
+func (h *MemStateHistory) AddState(_ context.Context, id ID, roots TreeRoots,
+	createdAt time.Time) (*StateRecord, error) {
+
+	state, err := roots.State()
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	records := h.states[id]
+	if findStateRecord(records, state) != nil {
+		return nil, ErrStateAlreadyExists
+	}
+
+	if len(records) > 0 {
+		latest := records[len(records)-1]
+		if createdAt.Before(latest.CreatedAt) {
+			return nil, fmt.Errorf(
+				"state creation time %v is before latest state creation "+
+					"time %v", createdAt, latest.CreatedAt)
+		}
+		latest.ReplacedByState = copyBigInt(state)
+		latest.ReplacedAt = createdAt
+	}
+
+	// the roots are copied, so the caller can't change the stored record
+	r := &StateRecord{ID: id, State: state, Roots: copyTreeRoots(roots),
+		CreatedAt: createdAt}
+	h.states[id] = append(records, r)
+	return copyStateRecord(r), nil
+}
+
+// LatestState returns the current state of identity
+func (h *MemStateHistory) LatestState(_ context.Context,
+	id ID) (*StateRecord, error) {
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	records := h.states[id]
+	if len(records) == 0 {
+		return nil, ErrStateNotFound
+	}
+	return copyStateRecord(records[len(records)-1]), nil
+}
+
+// StateAt returns the state of identity that was current at the time. The
+// state is current from its creation time (inclusive) to its replacement
+// time (exclusive).
+func (h *MemStateHistory) StateAt(_ context.Context, id ID,
+	t time.Time) (*StateRecord, error) {
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	// records are ordered by creation time, the latest state created not
+	// after t is not replaced before t
+	records := h.states[id]
+	for i := len(records) - 1; i >= 0; i-- {
+		if !records[i].CreatedAt.After(t) {
+			return copyStateRecord(records[i]), nil
+		}
+	}
+	return nil, ErrStateNotFound
+}
+
+// State returns the record of the state of identity
+func (h *MemStateHistory) State(_ context.Context, id ID,
+	state *big.Int) (*StateRecord, error) {
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	r := findStateRecord(h.states[id], state)
+	if r == nil {
+		return nil, ErrStateNotFound
+	}
+	return copyStateRecord(r), nil
+}
+
+// IsStateCurrent returns true if the state is the latest state of identity
+func (h *MemStateHistory) IsStateCurrent(ctx context.Context, id ID,
+	state *big.Int) (bool, error) {
+
+	r, err := h.State(ctx, id, state)
+	if err != nil {
+		return false, err
+	}
+	return r.IsCurrent(), nil
+}
+
+func findStateRecord(records []*StateRecord, state *big.Int) *StateRecord {
+	if state == nil {
+		return nil
+	}
+	for _, r := range records {
+		if r.State.Cmp(state) == 0 {
+			return r
+		}
+	}
+	return nil
+}
+
+// copyStateRecord returns deep copy of the record
+func copyStateRecord(r *StateRecord) *StateRecord {
+	c := *r
+	c.State = copyBigInt(r.State)
+	c.ReplacedByState = copyBigInt(r.ReplacedByState)
+	c.Roots = copyTreeRoots(r.Roots)
+	return &c
+}
+
+func copyTreeRoots(r TreeRoots) TreeRoots {
+	return TreeRoots{
+		ClaimsTreeRoot:     copyBigInt(r.ClaimsTreeRoot),
+		RevocationTreeRoot: copyBigInt(r.RevocationTreeRoot),
+		RootsTreeRoot:      copyBigInt(r.RootsTreeRoot),
+	}
+}
+
+func copyBigInt(i *big.Int) *big.Int {
+	if i == nil {
+		return nil
+	}
+	return new(big.Int).Set(i)
+}
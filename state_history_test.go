@@ -0,0 +1,197 @@
+package core
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testTreeRoots(clr int64) TreeRoots {
+	return TreeRoots{
+		ClaimsTreeRoot:     big.NewInt(clr),
+		RevocationTreeRoot: big.NewInt(0),
+		RootsTreeRoot:      big.NewInt(0),
+	}
+}
+
+func TestMemStateHistory(t *testing.T) {
+	ctx := context.Background()
+	var h StateHistory = NewMemStateHistory()
+
+	id := testGistID(t, 1)
+	otherID := testGistID(t, 2)
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	t3 := t2.Add(time.Hour)
+
+	_, err := h.LatestState(ctx, id)
+	require.ErrorIs(t, err, ErrStateNotFound)
+
+	r1, err := h.AddState(ctx, id, testTreeRoots(1), t1)
+	require.NoError(t, err)
+	require.Equal(t, testIdenState(t, 1), r1.State)
+	require.Equal(t, testTreeRoots(1), r1.Roots)
+	require.Equal(t, id, r1.ID)
+	require.True(t, r1.IsCurrent())
+
+	_, err = h.AddState(ctx, id, testTreeRoots(2), t2)
+	require.NoError(t, err)
+	r3, err := h.AddState(ctx, id, testTreeRoots(3), t3)
+	require.NoError(t, err)
+	_, err = h.AddState(ctx, otherID, testTreeRoots(1), t1)
+	require.NoError(t, err)
+
+	latest, err := h.LatestState(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, r3, latest)
+
+	// replaced state
+	r1, err = h.State(ctx, id, testIdenState(t, 1))
+	require.NoError(t, err)
+	require.False(t, r1.IsCurrent())
+	require.Equal(t, testIdenState(t, 2), r1.ReplacedByState)
+	require.Equal(t, t2, r1.ReplacedAt)
+
+	_, err = h.State(ctx, id, big.NewInt(100))
+	require.ErrorIs(t, err, ErrStateNotFound)
+
+	isCurrent, err := h.IsStateCurrent(ctx, id, testIdenState(t, 3))
+	require.NoError(t, err)
+	require.True(t, isCurrent)
+	isCurrent, err = h.IsStateCurrent(ctx, id, testIdenState(t, 2))
+	require.NoError(t, err)
+	require.False(t, isCurrent)
+	isCurrent, err = h.IsStateCurrent(ctx, otherID, testIdenState(t, 1))
+	require.NoError(t, err)
+	require.True(t, isCurrent)
+	_, err = h.IsStateCurrent(ctx, otherID, testIdenState(t, 2))
+	require.ErrorIs(t, err, ErrStateNotFound)
+
+	testCases := []struct {
+		at        time.Time
+		wantState int64
+	}{
+		{at: t1, wantState: 1},
+		{at: t1.Add(time.Minute), wantState: 1},
+		{at: t2.Add(-time.Nanosecond), wantState: 1},
+		{at: t2, wantState: 2},
+		{at: t3, wantState: 3},
+		{at: t3.Add(time.Hour), wantState: 3},
+	}
+	for _, tc := range testCases {
+		r, err := h.StateAt(ctx, id, tc.at)
+		require.NoError(t, err)
+		require.Equal(t, testIdenState(t, tc.wantState), r.State, tc.at)
+	}
+	_, err = h.StateAt(ctx, id, t1.Add(-time.Second))
+	require.ErrorIs(t, err, ErrStateNotFound)
+
+	// the returned records are copies
+	latest.ReplacedByState = big.NewInt(1)
+	latest, err = h.LatestState(ctx, id)
+	require.NoError(t, err)
+	require.True(t, latest.IsCurrent())
+}
+
+func TestMemStateHistory_DeepCopy(t *testing.T) {
+	ctx := context.Background()
+	h := NewMemStateHistory()
+	id := testGistID(t, 1)
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// the stored record does not share the roots of the caller
+	roots := testTreeRoots(1)
+	r1, err := h.AddState(ctx, id, roots, t1)
+	require.NoError(t, err)
+	roots.ClaimsTreeRoot.SetInt64(100)
+
+	// the returned records do not share numbers with the stored ones
+	r1.State.SetInt64(100)
+	r1.Roots.ClaimsTreeRoot.SetInt64(100)
+	r1.Roots.RevocationTreeRoot.SetInt64(100)
+	r1.Roots.RootsTreeRoot.SetInt64(100)
+
+	latest, err := h.LatestState(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, testIdenState(t, 1), latest.State)
+	require.Equal(t, testTreeRoots(1), latest.Roots)
+
+	latest.State.SetInt64(100)
+	latest.Roots.ClaimsTreeRoot.SetInt64(100)
+
+	_, err = h.AddState(ctx, id, testTreeRoots(2), t1.Add(time.Hour))
+	require.NoError(t, err)
+	r1, err = h.State(ctx, id, testIdenState(t, 1))
+	require.NoError(t, err)
+	require.Equal(t, testTreeRoots(1), r1.Roots)
+	r1.ReplacedByState.SetInt64(100)
+
+	r1, err = h.StateAt(ctx, id, t1)
+	require.NoError(t, err)
+	require.Equal(t, testIdenState(t, 1), r1.State)
+	require.Equal(t, testIdenState(t, 2), r1.ReplacedByState)
+}
+
+func TestMemStateHistory_AddStateErrors(t *testing.T) {
+	ctx := context.Background()
+	h := NewMemStateHistory()
+	id := testGistID(t, 1)
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := h.AddState(ctx, id, testTreeRoots(1), t1)
+	require.NoError(t, err)
+
+	_, err = h.AddState(ctx, id, testTreeRoots(1), t1.Add(time.Hour))
+	require.ErrorIs(t, err, ErrStateAlreadyExists)
+
+	_, err = h.AddState(ctx, id, testTreeRoots(2), t1.Add(-time.Hour))
+	require.Error(t, err)
+
+	_, err = h.AddState(ctx, id, TreeRoots{}, t1.Add(time.Hour))
+	require.Error(t, err)
+
+	latest, err := h.LatestState(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, testIdenState(t, 1), latest.State)
+	require.True(t, latest.IsCurrent())
+}
+
+func TestMemStateHistory_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	h := NewMemStateHistory()
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	ids := make([]ID, 8)
+	for i := range ids {
+		ids[i] = testGistID(t, int64(i+1))
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(ids)*5)
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id ID) {
+			defer wg.Done()
+			for j := int64(1); j <= 5; j++ {
+				_, err := h.AddState(ctx, id, testTreeRoots(j),
+					t1.Add(time.Duration(j)*time.Minute))
+				errs <- err
+			}
+		}(id)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	for _, id := range ids {
+		latest, err := h.LatestState(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, testIdenState(t, 5), latest.State)
+	}
+}
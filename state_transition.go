@@ -0,0 +1,268 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+)
+
+// ErrStateTransitionNotSigned returns when the state transition has no
+// signature
+var ErrStateTransitionNotSigned = errors.New("state transition is not signed")
+
+// StateTransition is the transition of identity from the old state to the
+// new state. It is authorized by the signature of
+// StateTransitionMessage(OldState, NewState) with the auth key of identity.
+type StateTransition struct {
+	ID       ID
+	OldState *big.Int
+	NewState *big.Int
+	// IsOldStateGenesis is true if ID is derived from the old state
+	IsOldStateGenesis bool
+
+	OldRoots TreeRoots
+	NewRoots TreeRoots
+
+	AuthClaim *Claim
+	// AuthClaimProof is the proof of inclusion of AuthClaim in the old
+	// claims tree
+	AuthClaimProof *MerkleTreeProof
+	// AuthClaimNonRevProof is the proof of non-inclusion of AuthClaim
+	// revocation nonce in the old revocation tree
+	AuthClaimNonRevProof *MerkleTreeProof
+	// NewAuthClaimProof is the proof of inclusion of AuthClaim in the new
+	// claims tree
+	NewAuthClaimProof *MerkleTreeProof
+
+	Signature *babyjub.Signature
+}
+
+// NewStateTransition creates the transition of identity from the state of
+// the old roots to the current state of identity. Identity trees must keep
+// the nodes of the old roots to generate proofs of the auth claim.
+func NewStateTransition(ctx context.Context, identity *Identity,
+	oldRoots TreeRoots) (*StateTransition, error) {
+
+	st := &StateTransition{
+		ID:        identity.ID,
+		OldRoots:  oldRoots,
+		NewRoots:  identity.Roots(),
+		AuthClaim: identity.AuthClaim.Clone(),
+	}
+
+	var err error
+	st.OldState, err = oldRoots.State()
+	if err != nil {
+		return nil, err
+	}
+	st.NewState, err = st.NewRoots.State()
+	if err != nil {
+		return nil, err
+	}
+	if st.OldState.Cmp(st.NewState) == 0 {
+		return nil, errors.New("new state is equal to old state")
+	}
+
+	st.IsOldStateGenesis, err = CheckGenesisStateID(st.ID.BigInt(),
+		st.OldState)
+	if err != nil {
+		return nil, err
+	}
+
+	hi, hv, err := st.AuthClaim.HiHv()
+	if err != nil {
+		return nil, err
+	}
+
+	var v *big.Int
+	st.AuthClaimProof, v, err = identity.ClaimsTree.GenerateProof(ctx, hi,
+		oldRoots.ClaimsTreeRoot)
+	if err != nil {
+		return nil, fmt.Errorf("can't generate auth claim proof: %w", err)
+	}
+	if !st.AuthClaimProof.Existence || v.Cmp(hv) != 0 {
+		return nil, errors.New("auth claim is not in old claims tree")
+	}
+
+	st.NewAuthClaimProof, v, err = identity.ClaimsTree.GenerateProof(ctx, hi,
+		st.NewRoots.ClaimsTreeRoot)
+	if err != nil {
+		return nil, fmt.Errorf("can't generate auth claim proof: %w", err)
+	}
+	if !st.NewAuthClaimProof.Existence || v.Cmp(hv) != 0 {
+		return nil, errors.New("auth claim is not in new claims tree")
+	}
+
+	revNonce := new(big.Int).SetUint64(st.AuthClaim.GetRevocationNonce())
+	st.AuthClaimNonRevProof, _, err = identity.RevocationTree.GenerateProof(
+		ctx, revNonce, oldRoots.RevocationTreeRoot)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"can't generate auth claim non-revocation proof: %w", err)
+	}
+	if st.AuthClaimNonRevProof.Existence {
+		return nil, errors.New("auth claim is revoked in old state")
+	}
+
+	return st, nil
+}
+
+// StateTransitionMessage returns the message that is signed to authorize the
+// state transition, Poseidon(oldState, newState).
+func StateTransitionMessage(oldState, newState *big.Int) (*big.Int, error) {
+	return poseidon.Hash([]*big.Int{oldState, newState})
+}
+
+// Sign signs the state transition with BabyJubJub auth key. The public key
+// must match AuthClaim.
+func (st *StateTransition) Sign(k *babyjub.PrivateKey) error {
+	pubKey, err := st.authPubKey()
+	if err != nil {
+		return err
+	}
+	kPub := k.Public()
+	if kPub.X.Cmp(pubKey.X) != 0 || kPub.Y.Cmp(pubKey.Y) != 0 {
+		return errors.New("private key does not match auth claim")
+	}
+
+	msg, err := StateTransitionMessage(st.OldState, st.NewState)
+	if err != nil {
+		return err
+	}
+	st.Signature = k.SignPoseidon(msg)
+	return nil
+}
+
+// VerifySignature verifies the signature of the state transition by the key
+// of AuthClaim
+func (st *StateTransition) VerifySignature() error {
+	if st.Signature == nil {
+		return ErrStateTransitionNotSigned
+	}
+	pubKey, err := st.authPubKey()
+	if err != nil {
+		return err
+	}
+	msg, err := StateTransitionMessage(st.OldState, st.NewState)
+	if err != nil {
+		return err
+	}
+	if !pubKey.VerifyPoseidon(msg, st.Signature) {
+		return errors.New("invalid state transition signature")
+	}
+	return nil
+}
+
+func (st *StateTransition) authPubKey() (*babyjub.PublicKey, error) {
+	if st.AuthClaim == nil || st.AuthClaim.GetSchemaHash() != AuthSchemaHash {
+		return nil, errors.New("auth claim is not BabyJubJub auth claim")
+	}
+	pubKey := &babyjub.PublicKey{
+		X: st.AuthClaim.index[2].ToInt(),
+		Y: st.AuthClaim.index[3].ToInt(),
+	}
+	if !pubKey.Point().InCurve() {
+		return nil, errors.New("invalid BabyJubJub public key of auth claim")
+	}
+	return pubKey, nil
+}
+
+type stateTransitionInputs struct {
+	AuthClaim               *Claim   `json:"authClaim"`
+	AuthClaimMtp            []string `json:"authClaimMtp"`
+	AuthClaimNonRevMtp      []string `json:"authClaimNonRevMtp"`
+	AuthClaimNonRevMtpAuxHi string   `json:"authClaimNonRevMtpAuxHi"`
+	AuthClaimNonRevMtpAuxHv string   `json:"authClaimNonRevMtpAuxHv"`
+	AuthClaimNonRevMtpNoAux string   `json:"authClaimNonRevMtpNoAux"`
+	UserID                  string   `json:"userID"`
+	NewUserState            string   `json:"newUserState"`
+	OldUserState            string   `json:"oldUserState"`
+	IsOldStateGenesis       string   `json:"isOldStateGenesis"`
+	ClaimsTreeRoot          string   `json:"claimsTreeRoot"`
+	RevTreeRoot             string   `json:"revTreeRoot"`
+	RootsTreeRoot           string   `json:"rootsTreeRoot"`
+	SignatureR8X            string   `json:"signatureR8x"`
+	SignatureR8Y            string   `json:"signatureR8y"`
+	SignatureS              string   `json:"signatureS"`
+	NewAuthClaimMtp         []string `json:"newAuthClaimMtp"`
+	NewClaimsTreeRoot       string   `json:"newClaimsTreeRoot"`
+	NewRevTreeRoot          string   `json:"newRevTreeRoot"`
+	NewRootsTreeRoot        string   `json:"newRootsTreeRoot"`
+}
+
+// InputsMarshal returns JSON inputs of the state transition circuit with
+// trees of the given number of levels (IdentityTreeLevels is used by iden3
+// circuits). The state transition must be signed.
+func (st *StateTransition) InputsMarshal(levels int) ([]byte, error) {
+	if st.Signature == nil {
+		return nil, ErrStateTransitionNotSigned
+	}
+
+	var err error
+	in := stateTransitionInputs{
+		AuthClaim:         st.AuthClaim,
+		UserID:            st.ID.BigInt().String(),
+		NewUserState:      st.NewState.String(),
+		OldUserState:      st.OldState.String(),
+		IsOldStateGenesis: boolToCircuitInput(st.IsOldStateGenesis),
+		ClaimsTreeRoot:    st.OldRoots.ClaimsTreeRoot.String(),
+		RevTreeRoot:       st.OldRoots.RevocationTreeRoot.String(),
+		RootsTreeRoot:     st.OldRoots.RootsTreeRoot.String(),
+		SignatureR8X:      st.Signature.R8.X.String(),
+		SignatureR8Y:      st.Signature.R8.Y.String(),
+		SignatureS:        st.Signature.S.String(),
+		NewClaimsTreeRoot: st.NewRoots.ClaimsTreeRoot.String(),
+		NewRevTreeRoot:    st.NewRoots.RevocationTreeRoot.String(),
+		NewRootsTreeRoot:  st.NewRoots.RootsTreeRoot.String(),
+	}
+
+	in.AuthClaimMtp, err = circuitSiblings(st.AuthClaimProof, levels)
+	if err != nil {
+		return nil, err
+	}
+	in.NewAuthClaimMtp, err = circuitSiblings(st.NewAuthClaimProof, levels)
+	if err != nil {
+		return nil, err
+	}
+	in.AuthClaimNonRevMtp, err = circuitSiblings(st.AuthClaimNonRevProof,
+		levels)
+	if err != nil {
+		return nil, err
+	}
+
+	if aux := st.AuthClaimNonRevProof.NodeAux; aux != nil {
+		in.AuthClaimNonRevMtpAuxHi = aux.Key.String()
+		in.AuthClaimNonRevMtpAuxHv = aux.Value.String()
+		in.AuthClaimNonRevMtpNoAux = boolToCircuitInput(false)
+	} else {
+		in.AuthClaimNonRevMtpAuxHi = "0"
+		in.AuthClaimNonRevMtpAuxHv = "0"
+		in.AuthClaimNonRevMtpNoAux = boolToCircuitInput(true)
+	}
+
+	return json.Marshal(in)
+}
+
+func circuitSiblings(p *MerkleTreeProof, levels int) ([]string, error) {
+	siblings, err := p.CircuitSiblings(levels)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, len(siblings))
+	for i, s := range siblings {
+		res[i] = s.String()
+	}
+	return res, nil
+}
+
+func boolToCircuitInput(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
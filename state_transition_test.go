@@ -0,0 +1,186 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/stretchr/testify/require"
+)
+
+// testAddClaim adds the claim to the claims tree and the new claims tree
+// root to the roots tree
+func testAddClaim(t testing.TB, identity *Identity, c *Claim) {
+	t.Helper()
+	hi, hv, err := c.HiHv()
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, identity.ClaimsTree.Add(ctx, hi, hv))
+	require.NoError(t, identity.RootsTree.Add(ctx, identity.ClaimsTree.Root(),
+		big.NewInt(0)))
+}
+
+func TestStateTransition(t *testing.T) {
+	ctx := context.Background()
+	k := testBJJKey(t)
+	identity, err := NewIdentityFromAuthKey(ctx, k.Public(),
+		DIDMethodPolygonID, Polygon, Mumbai, testIdentityTrees())
+	require.NoError(t, err)
+
+	genesisRoots := identity.Roots()
+	claim, err := NewClaim(SchemaHash{1}, WithIndexDataInts(big.NewInt(1),
+		nil), WithRevocationNonce(10))
+	require.NoError(t, err)
+	testAddClaim(t, identity, claim)
+
+	st, err := NewStateTransition(ctx, identity, genesisRoots)
+	require.NoError(t, err)
+	require.Equal(t, identity.ID, st.ID)
+	require.Equal(t, identity.GenesisState, st.OldState)
+	newState, err := identity.State()
+	require.NoError(t, err)
+	require.Equal(t, newState, st.NewState)
+	require.True(t, st.IsOldStateGenesis)
+	require.Equal(t, genesisRoots, st.OldRoots)
+	require.Equal(t, identity.Roots(), st.NewRoots)
+
+	hi, hv, err := identity.AuthClaim.HiHv()
+	require.NoError(t, err)
+	require.True(t, VerifyMerkleTreeProof(genesisRoots.ClaimsTreeRoot,
+		st.AuthClaimProof, hi, hv))
+	require.True(t, VerifyMerkleTreeProof(st.NewRoots.ClaimsTreeRoot,
+		st.NewAuthClaimProof, hi, hv))
+	require.True(t, VerifyMerkleTreeProof(genesisRoots.RevocationTreeRoot,
+		st.AuthClaimNonRevProof, big.NewInt(0), nil))
+
+	err = st.VerifySignature()
+	require.ErrorIs(t, err, ErrStateTransitionNotSigned)
+	_, err = st.InputsMarshal(IdentityTreeLevels)
+	require.ErrorIs(t, err, ErrStateTransitionNotSigned)
+
+	otherKey := babyjub.PrivateKey{1}
+	require.Error(t, st.Sign(&otherKey))
+
+	require.NoError(t, st.Sign(&k))
+	require.NoError(t, st.VerifySignature())
+	msg, err := poseidon.Hash([]*big.Int{st.OldState, st.NewState})
+	require.NoError(t, err)
+	require.True(t, k.Public().VerifyPoseidon(msg, st.Signature))
+
+	st2 := *st
+	st2.NewState = big.NewInt(1)
+	require.Error(t, st2.VerifySignature())
+
+	inputsJSON, err := st.InputsMarshal(IdentityTreeLevels)
+	require.NoError(t, err)
+
+	var inputs map[string]interface{}
+	require.NoError(t, json.Unmarshal(inputsJSON, &inputs))
+	require.Len(t, inputs, 20)
+	require.Equal(t, identity.ID.BigInt().String(), inputs["userID"])
+	require.Equal(t, st.OldState.String(), inputs["oldUserState"])
+	require.Equal(t, st.NewState.String(), inputs["newUserState"])
+	require.Equal(t, "1", inputs["isOldStateGenesis"])
+	require.Equal(t, genesisRoots.ClaimsTreeRoot.String(),
+		inputs["claimsTreeRoot"])
+	require.Equal(t, "0", inputs["revTreeRoot"])
+	require.Equal(t, "0", inputs["rootsTreeRoot"])
+	require.Equal(t, st.NewRoots.ClaimsTreeRoot.String(),
+		inputs["newClaimsTreeRoot"])
+	require.Equal(t, "0", inputs["newRevTreeRoot"])
+	require.Equal(t, st.NewRoots.RootsTreeRoot.String(),
+		inputs["newRootsTreeRoot"])
+	require.Equal(t, st.Signature.R8.X.String(), inputs["signatureR8x"])
+	require.Equal(t, st.Signature.R8.Y.String(), inputs["signatureR8y"])
+	require.Equal(t, st.Signature.S.String(), inputs["signatureS"])
+	require.Equal(t, "0", inputs["authClaimNonRevMtpAuxHi"])
+	require.Equal(t, "0", inputs["authClaimNonRevMtpAuxHv"])
+	require.Equal(t, "1", inputs["authClaimNonRevMtpNoAux"])
+	require.Len(t, inputs["authClaimMtp"], IdentityTreeLevels)
+	require.Len(t, inputs["authClaimNonRevMtp"], IdentityTreeLevels)
+	require.Len(t, inputs["newAuthClaimMtp"], IdentityTreeLevels)
+
+	claimJSON, err := json.Marshal(identity.AuthClaim)
+	require.NoError(t, err)
+	authClaimInput, err := json.Marshal(inputs["authClaim"])
+	require.NoError(t, err)
+	require.JSONEq(t, string(claimJSON), string(authClaimInput))
+}
+
+func TestStateTransition_NotGenesis(t *testing.T) {
+	ctx := context.Background()
+	k := testBJJKey(t)
+	identity, err := NewIdentityFromAuthKey(ctx, k.Public(),
+		DIDMethodPolygonID, Polygon, Mumbai, testIdentityTrees(),
+		WithAuthClaimRevocationNonce(5))
+	require.NoError(t, err)
+
+	claim, err := NewClaim(SchemaHash{1}, WithIndexDataInts(big.NewInt(1),
+		nil), WithRevocationNonce(10))
+	require.NoError(t, err)
+	testAddClaim(t, identity, claim)
+	require.NoError(t,
+		identity.RevocationTree.Add(ctx, big.NewInt(10), big.NewInt(0)))
+
+	oldRoots := identity.Roots()
+	claim2, err := NewClaim(SchemaHash{1}, WithIndexDataInts(big.NewInt(2),
+		nil), WithRevocationNonce(11))
+	require.NoError(t, err)
+	testAddClaim(t, identity, claim2)
+
+	st, err := NewStateTransition(ctx, identity, oldRoots)
+	require.NoError(t, err)
+	require.False(t, st.IsOldStateGenesis)
+	require.NoError(t, st.Sign(&k))
+
+	inputsJSON, err := st.InputsMarshal(IdentityTreeLevels)
+	require.NoError(t, err)
+	var inputs map[string]interface{}
+	require.NoError(t, json.Unmarshal(inputsJSON, &inputs))
+	require.Equal(t, "0", inputs["isOldStateGenesis"])
+	// revocation tree has single leaf of the nonce 10
+	require.Equal(t, "10", inputs["authClaimNonRevMtpAuxHi"])
+	require.Equal(t, "0", inputs["authClaimNonRevMtpAuxHv"])
+	require.Equal(t, "0", inputs["authClaimNonRevMtpNoAux"])
+
+	_, err = st.InputsMarshal(0)
+	require.Error(t, err)
+}
+
+func TestNewStateTransition_Errors(t *testing.T) {
+	ctx := context.Background()
+	k := testBJJKey(t)
+	identity, err := NewIdentityFromAuthKey(ctx, k.Public(),
+		DIDMethodPolygonID, Polygon, Mumbai, testIdentityTrees())
+	require.NoError(t, err)
+
+	// state is not changed
+	_, err = NewStateTransition(ctx, identity, identity.Roots())
+	require.Error(t, err)
+
+	// auth claim is revoked
+	oldRoots := identity.Roots()
+	require.NoError(t,
+		identity.RevocationTree.Add(ctx, big.NewInt(0), big.NewInt(0)))
+	oldRevokedRoots := identity.Roots()
+	claim, err := NewClaim(SchemaHash{1}, WithIndexDataInts(big.NewInt(1),
+		nil))
+	require.NoError(t, err)
+	testAddClaim(t, identity, claim)
+	_, err = NewStateTransition(ctx, identity, oldRevokedRoots)
+	require.Error(t, err)
+
+	// unknown old roots
+	_, err = NewStateTransition(ctx, identity, TreeRoots{
+		ClaimsTreeRoot:     big.NewInt(1),
+		RevocationTreeRoot: oldRoots.RevocationTreeRoot,
+		RootsTreeRoot:      oldRoots.RootsTreeRoot,
+	})
+	require.Error(t, err)
+
+	_, err = NewStateTransition(ctx, identity, TreeRoots{})
+	require.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+)
+
+// ErrUnsupportedDIDNotFound returns when there is no DID stored for the
+// given ID in UnsupportedDIDStore.
+var ErrUnsupportedDIDNotFound = errors.New("unsupported DID not found in store")
+
+// UnsupportedDIDStore keeps mappings from IDs of unsupported DID methods
+// (created from sha256 of the DID) back to the original DIDs. The hash
+// can't be reversed, so the only way to restore the DID is to remember it
+// at the moment the ID is created.
+type UnsupportedDIDStore interface {
+	// Put saves mapping from id to did.
+	Put(id ID, did w3c.DID) error
+	// Get returns DID for id. Returns ErrUnsupportedDIDNotFound if there is
+	// no such mapping.
+	Get(id ID) (*w3c.DID, error)
+}
+
+// InMemoryUnsupportedDIDStore is an UnsupportedDIDStore that keeps all
+// mappings in memory. It is safe for concurrent use.
+type InMemoryUnsupportedDIDStore struct {
+	mu   sync.RWMutex
+	dids map[ID]w3c.DID
+}
+
+// NewInMemoryUnsupportedDIDStore creates new empty InMemoryUnsupportedDIDStore
+func NewInMemoryUnsupportedDIDStore() *InMemoryUnsupportedDIDStore {
+	return &InMemoryUnsupportedDIDStore{dids: make(map[ID]w3c.DID)}
+}
+
+// Put saves mapping from id to did.
+func (s *InMemoryUnsupportedDIDStore) Put(id ID, did w3c.DID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dids[id] = did.Clone()
+	return nil
+}
+
+// Get returns DID for id. Returns ErrUnsupportedDIDNotFound if there is
+// no such mapping.
+func (s *InMemoryUnsupportedDIDStore) Get(id ID) (*w3c.DID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	did, ok := s.dids[id]
+	if !ok {
+		return nil, ErrUnsupportedDIDNotFound
+	}
+	did = did.Clone()
+	return &did, nil
+}
+
+// IDFromDIDWithStore works like IDFromDID, but when the DID method is
+// unknown it also records the mapping from the resulting ID to the DID in
+// the store, so the DID could be restored later with
+// ParseDIDFromIDWithStore.
+func IDFromDIDWithStore(did w3c.DID, store UnsupportedDIDStore) (ID, error) {
+	id, err := idFromDID(did)
+	if !errors.Is(err, ErrMethodUnknown) {
+		return id, err
+	}
+
+	id = newIDFromUnsupportedDID(did)
+	err = store.Put(id, did)
+	if err != nil {
+		return ID{}, err
+	}
+	return id, nil
+}
+
+// ParseDIDFromIDWithStore returns DID from ID. For IDs of unsupported DID
+// methods the DID is looked up in the store.
+func ParseDIDFromIDWithStore(id ID, store UnsupportedDIDStore) (*w3c.DID,
+	error) {
+
+	did, err := ParseDIDFromID(id)
+	if !errors.Is(err, ErrMethodUnknown) {
+		return did, err
+	}
+
+	did, err = store.Get(id)
+	if errors.Is(err, ErrUnsupportedDIDNotFound) {
+		return nil, fmt.Errorf("%w: %v", ErrMethodUnknown, err)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return did, nil
+}
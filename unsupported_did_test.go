@@ -0,0 +1,68 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDIDFromIDWithStore(t *testing.T) {
+	store := NewInMemoryUnsupportedDIDStore()
+
+	did, err := w3c.ParseDID("did:something:x")
+	require.NoError(t, err)
+
+	id, err := IDFromDIDWithStore(*did, store)
+	require.NoError(t, err)
+
+	id2, err := IDFromDID(*did)
+	require.NoError(t, err)
+	require.Equal(t, id2, id)
+
+	_, err = ParseDIDFromID(id)
+	require.ErrorIs(t, err, ErrMethodUnknown)
+
+	did2, err := ParseDIDFromIDWithStore(id, store)
+	require.NoError(t, err)
+	require.Equal(t, did, did2)
+	require.Equal(t, "did:something:x", did2.String())
+
+	// modification of returned DID does not change the stored one
+	did2.IDStrings[0] = "y"
+	did3, err := ParseDIDFromIDWithStore(id, store)
+	require.NoError(t, err)
+	require.Equal(t, did, did3)
+}
+
+func TestParseDIDFromIDWithStore_NotFound(t *testing.T) {
+	store := NewInMemoryUnsupportedDIDStore()
+
+	did, err := w3c.ParseDID("did:something:x")
+	require.NoError(t, err)
+	id, err := IDFromDID(*did)
+	require.NoError(t, err)
+
+	_, err = ParseDIDFromIDWithStore(id, store)
+	require.ErrorIs(t, err, ErrMethodUnknown)
+}
+
+func TestParseDIDFromIDWithStore_Iden3DID(t *testing.T) {
+	store := NewInMemoryUnsupportedDIDStore()
+
+	didStr := "did:iden3:polygon:mumbai:wyFiV4w71QgWPn6bYLsZoysFay66gKtVa9kfu6yMZ"
+	did, err := w3c.ParseDID(didStr)
+	require.NoError(t, err)
+
+	id, err := IDFromDIDWithStore(*did, store)
+	require.NoError(t, err)
+	require.Equal(t, "wyFiV4w71QgWPn6bYLsZoysFay66gKtVa9kfu6yMZ", id.String())
+
+	// supported DIDs are not saved to the store
+	_, err = store.Get(id)
+	require.ErrorIs(t, err, ErrUnsupportedDIDNotFound)
+
+	did2, err := ParseDIDFromIDWithStore(id, store)
+	require.NoError(t, err)
+	require.Equal(t, didStr, did2.String())
+}
@@ -0,0 +1,198 @@
+package w3c
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ErrInvalidDIDURL returns when DID URL can't be dereferenced because it is
+// malformed.
+var ErrInvalidDIDURL = errors.New("invalidDidUrl")
+
+// DereferencingResult is a result of DID URL dereferencing. Only one of
+// Document, VerificationMethod, Service and RedirectURL is set.
+// https://www.w3.org/TR/did-core/#did-url-dereferencing
+type DereferencingResult struct {
+	// Document is set when DID URL has no fragment, path and service
+	// selection.
+	Document *DIDDocument
+	// VerificationMethod is set when DID URL fragment references a
+	// verification method.
+	VerificationMethod *VerificationMethod
+	// Service is set when DID URL fragment references a service, or the
+	// service is selected by `service` parameter, but its endpoint is not a
+	// URI.
+	Service *Service
+	// RedirectURL is set when the service with URI endpoint is selected by
+	// `service` parameter. It is the service endpoint combined with
+	// `relativeRef` parameter and DID URL fragment.
+	RedirectURL string
+
+	// DocumentMetadata is a metadata of resolved DID document.
+	DocumentMetadata DocumentMetadata
+}
+
+// Dereference resolves DID of didURL with resolver and selects the resource
+// referenced by DID URL: a verification method or a service by fragment
+// (`did:example:123#key-1`), or the service endpoint by `service` parameter
+// (`did:example:123?service=agent&relativeRef=/path`). When the DID URL
+// has no fragment or service parameter, the DID document itself is
+// returned.
+//
+// Returns ErrNotFound if the referenced resource does not exist in the DID
+// document, ErrInvalidDIDURL if the DID URL is malformed and any error
+// returned by resolver.
+func Dereference(ctx context.Context, resolver Resolver,
+	didURL DID) (*DereferencingResult, error) {
+
+	params, err := didURLParams(didURL)
+	if err != nil {
+		return nil, err
+	}
+
+	baseDID := DID{Method: didURL.Method, ID: didURL.ID,
+		IDStrings: didURL.IDStrings}
+	doc, _, docMeta, err := resolver.Resolve(ctx, baseDID,
+		ResolutionOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &DereferencingResult{DocumentMetadata: docMeta}
+
+	if serviceID := params.Get(ParamService); serviceID != "" {
+		srv := findService(doc, serviceID)
+		if srv == nil {
+			return nil, fmt.Errorf("%w: service %q", ErrNotFound, serviceID)
+		}
+		if srv.ServiceEndpoint.URI == "" {
+			res.Service = srv
+			return res, nil
+		}
+		res.RedirectURL, err = serviceEndpointURL(srv.ServiceEndpoint.URI,
+			params.Get(ParamRelativeRef), didURL.Fragment)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+
+	if didURL.Path != "" || len(didURL.PathSegments) > 0 {
+		return nil, fmt.Errorf(
+			"%w: dereferencing of DID URL path is not supported",
+			ErrNotFound)
+	}
+
+	if didURL.Fragment == "" {
+		res.Document = doc
+		return res, nil
+	}
+
+	if vm := findVerificationMethod(doc, didURL.Fragment); vm != nil {
+		res.VerificationMethod = vm
+		return res, nil
+	}
+	if srv := findService(doc, didURL.Fragment); srv != nil {
+		res.Service = srv
+		return res, nil
+	}
+
+	return nil, fmt.Errorf("%w: fragment %q", ErrNotFound, didURL.Fragment)
+}
+
+// didURLParams returns DID URL parameters from query and from legacy
+// `;name=value` params.
+func didURLParams(didURL DID) (url.Values, error) {
+	params, err := didURL.QueryValues()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDIDURL, err)
+	}
+
+	for _, p := range didURL.Params {
+		value, err := url.PathUnescape(p.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidDIDURL, err)
+		}
+		params.Add(p.Name, value)
+	}
+
+	return params, nil
+}
+
+// isSameResource returns true if id is a DID URL of the document subject
+// with the fragment.
+func isSameResource(doc *DIDDocument, id DID, fragment string) bool {
+	return id.Fragment == fragment && id.Method == doc.ID.Method &&
+		id.ID == doc.ID.ID && id.Query == "" && id.Path == "" &&
+		len(id.Params) == 0
+}
+
+func findVerificationMethod(doc *DIDDocument,
+	fragment string) *VerificationMethod {
+
+	for i := range doc.VerificationMethod {
+		if isSameResource(doc, doc.VerificationMethod[i].ID, fragment) {
+			return &doc.VerificationMethod[i]
+		}
+	}
+
+	relationships := [][]VerificationRelationship{
+		doc.Authentication,
+		doc.AssertionMethod,
+		doc.KeyAgreement,
+		doc.CapabilityInvocation,
+		doc.CapabilityDelegation,
+	}
+	for _, rel := range relationships {
+		for i := range rel {
+			if rel[i].Embedded != nil &&
+				isSameResource(doc, rel[i].Embedded.ID, fragment) {
+
+				return rel[i].Embedded
+			}
+		}
+	}
+
+	return nil
+}
+
+func findService(doc *DIDDocument, fragment string) *Service {
+	for i := range doc.Service {
+		if isSameResource(doc, doc.Service[i].ID, fragment) {
+			return &doc.Service[i]
+		}
+	}
+	return nil
+}
+
+// serviceEndpointURL builds the output URL from service endpoint, relative
+// reference and fragment
+// https://w3c-ccg.github.io/did-resolution/#dereferencing-algorithm-primary
+func serviceEndpointURL(endpoint, relativeRef,
+	fragment string) (string, error) {
+
+	out, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid service endpoint: %w", err)
+	}
+
+	if relativeRef != "" {
+		ref, err := url.Parse(relativeRef)
+		if err != nil {
+			return "", fmt.Errorf("%w: invalid relativeRef: %v",
+				ErrInvalidDIDURL, err)
+		}
+		out = out.ResolveReference(ref)
+	}
+
+	if fragment != "" && out.Fragment == "" {
+		out.Fragment, err = url.PathUnescape(fragment)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidDIDURL, err)
+		}
+	}
+
+	return out.String(), nil
+}
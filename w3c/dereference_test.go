@@ -0,0 +1,133 @@
+package w3c
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testDocResolver(t testing.TB) Resolver {
+	t.Helper()
+	var doc DIDDocument
+	err := json.Unmarshal([]byte(testDIDDocument), &doc)
+	require.NoError(t, err)
+
+	r := &memResolver{docs: map[string]*DIDDocument{doc.ID.String(): &doc}}
+	mr := NewMultiResolver()
+	mr.Register("example", r)
+	return mr
+}
+
+func TestDereference(t *testing.T) {
+	ctx := context.Background()
+	r := testDocResolver(t)
+
+	testCases := []struct {
+		didURL string
+		check  func(t testing.TB, res *DereferencingResult)
+	}{
+		{
+			didURL: "did:example:123456789abcdefghi",
+			check: func(t testing.TB, res *DereferencingResult) {
+				require.NotNil(t, res.Document)
+				require.Equal(t, "did:example:123456789abcdefghi",
+					res.Document.ID.String())
+			},
+		},
+		{
+			didURL: "did:example:123456789abcdefghi#key-1",
+			check: func(t testing.TB, res *DereferencingResult) {
+				require.Nil(t, res.Document)
+				require.NotNil(t, res.VerificationMethod)
+				require.Equal(t, "JsonWebKey2020", res.VerificationMethod.Type)
+			},
+		},
+		{
+			// embedded verification method
+			didURL: "did:example:123456789abcdefghi#key-3",
+			check: func(t testing.TB, res *DereferencingResult) {
+				require.NotNil(t, res.VerificationMethod)
+				require.Equal(t,
+					"z6MkmM42vxfqZQsv4ehtTjFFxQ4sQKS2w6WR7emozFAn5cxu",
+					res.VerificationMethod.PublicKeyMultibase)
+			},
+		},
+		{
+			didURL: "did:example:123456789abcdefghi#linked-domain",
+			check: func(t testing.TB, res *DereferencingResult) {
+				require.Nil(t, res.VerificationMethod)
+				require.NotNil(t, res.Service)
+				require.Equal(t, []string{"LinkedDomains"}, res.Service.Type)
+			},
+		},
+		{
+			didURL: "did:example:123456789abcdefghi?service=linked-domain",
+			check: func(t testing.TB, res *DereferencingResult) {
+				require.Nil(t, res.Service)
+				require.Equal(t, "https://bar.example.com", res.RedirectURL)
+			},
+		},
+		{
+			didURL: "did:example:123456789abcdefghi?service=linked-domain&relativeRef=%2Fsome%2Fpath%3Fquery#frag",
+			check: func(t testing.TB, res *DereferencingResult) {
+				require.Equal(t, "https://bar.example.com/some/path?query#frag",
+					res.RedirectURL)
+			},
+		},
+		{
+			didURL: "did:example:123456789abcdefghi;service=linked-domain",
+			check: func(t testing.TB, res *DereferencingResult) {
+				require.Equal(t, "https://bar.example.com", res.RedirectURL)
+			},
+		},
+		{
+			// service endpoint is not URI
+			didURL: "did:example:123456789abcdefghi?service=hub",
+			check: func(t testing.TB, res *DereferencingResult) {
+				require.Empty(t, res.RedirectURL)
+				require.NotNil(t, res.Service)
+				require.Equal(t, "hub", res.Service.ID.Fragment)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.didURL, func(t *testing.T) {
+			didURL := mustParseDID(t, tc.didURL)
+			res, err := Dereference(ctx, r, didURL)
+			require.NoError(t, err)
+			tc.check(t, res)
+		})
+	}
+}
+
+func TestDereference_Errors(t *testing.T) {
+	ctx := context.Background()
+	r := testDocResolver(t)
+
+	testCases := []struct {
+		didURL  string
+		wantErr error
+	}{
+		{"did:example:123456789abcdefghi#key-100", ErrNotFound},
+		{"did:example:123456789abcdefghi?service=unknown", ErrNotFound},
+		{"did:example:123456789abcdefghi/some/path", ErrNotFound},
+		{"did:example:unknown#key-1", ErrNotFound},
+		{"did:other:123456789abcdefghi#key-1", ErrMethodNotSupported},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.didURL, func(t *testing.T) {
+			_, err := Dereference(ctx, r, mustParseDID(t, tc.didURL))
+			require.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+
+	// parser does not accept invalid percent-encoding, so set query manually
+	didURL := mustParseDID(t, "did:example:123456789abcdefghi")
+	didURL.Query = "service=%zz"
+	_, err := Dereference(ctx, r, didURL)
+	require.ErrorIs(t, err, ErrInvalidDIDURL)
+}
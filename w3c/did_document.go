@@ -0,0 +1,591 @@
+package w3c
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// DIDContextV1 is the JSON-LD context of DID documents
+// https://www.w3.org/TR/did-core/#json-ld
+const DIDContextV1 = "https://www.w3.org/ns/did/v1"
+
+// DIDDocument represents a DID document as described in the DID Core data
+// model https://www.w3.org/TR/did-core/#data-model
+//
+// All identifier fields are DIDs or DID URLs. The only exception is
+// AlsoKnownAs which may contain any URI.
+type DIDDocument struct {
+	// Context is a JSON-LD context of the document. Each item is a string
+	// or an embedded context object.
+	// https://www.w3.org/TR/did-core/#json-ld
+	Context []interface{}
+
+	// https://www.w3.org/TR/did-core/#did-subject
+	ID DID
+
+	// https://www.w3.org/TR/did-core/#also-known-as
+	AlsoKnownAs []string
+
+	// https://www.w3.org/TR/did-core/#did-controller
+	Controller []DID
+
+	// https://www.w3.org/TR/did-core/#verification-methods
+	VerificationMethod []VerificationMethod
+
+	// Verification relationships
+	// https://www.w3.org/TR/did-core/#verification-relationships
+	Authentication       []VerificationRelationship
+	AssertionMethod      []VerificationRelationship
+	KeyAgreement         []VerificationRelationship
+	CapabilityInvocation []VerificationRelationship
+	CapabilityDelegation []VerificationRelationship
+
+	// https://www.w3.org/TR/did-core/#services
+	Service []Service
+
+	// Properties holds all other properties of the document, e.g. ones
+	// defined by DID method or extensions.
+	Properties map[string]interface{}
+}
+
+// VerificationMethod represents a verification method of DID document
+// https://www.w3.org/TR/did-core/#verification-methods
+type VerificationMethod struct {
+	ID   DID
+	Type string
+	// Controller is omitted from JSON if empty
+	Controller DID
+
+	// https://www.w3.org/TR/did-core/#dfn-publickeyjwk
+	PublicKeyJwk *JSONWebKey
+	// https://www.w3.org/TR/did-core/#dfn-publickeymultibase
+	PublicKeyMultibase string
+
+	// Properties holds all other properties of verification method, e.g.
+	// ones defined by the verification method type.
+	Properties map[string]interface{}
+}
+
+// JSONWebKey is a public key in JWK format (RFC 7517).
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+}
+
+// VerificationRelationship is an item of verification relationship
+// (authentication, assertionMethod, etc.). It is either a reference to the
+// verification method or an embedded verification method.
+// https://www.w3.org/TR/did-core/#verification-relationships
+type VerificationRelationship struct {
+	// Reference is a DID URL of referenced verification method. Used
+	// when Embedded is nil.
+	Reference DID
+	// Embedded is a verification method embedded into relationship.
+	Embedded *VerificationMethod
+}
+
+// ID returns DID URL of the verification method, either referenced or
+// embedded.
+func (r VerificationRelationship) ID() DID {
+	if r.Embedded != nil {
+		return r.Embedded.ID
+	}
+	return r.Reference
+}
+
+// Service represents a service of DID document
+// https://www.w3.org/TR/did-core/#services
+type Service struct {
+	ID DID
+	// Type is a service type. DID Core allows to set one type or a set of
+	// types.
+	Type            []string
+	ServiceEndpoint ServiceEndpoint
+
+	// Properties holds all other properties of service.
+	Properties map[string]interface{}
+}
+
+// ServiceEndpoint is a value of serviceEndpoint property. It is a URI,
+// a map or a set composed of URIs and maps. Only one field is set.
+// https://www.w3.org/TR/did-core/#dfn-serviceendpoint
+type ServiceEndpoint struct {
+	URI string
+	Map map[string]interface{}
+	Set []ServiceEndpoint
+}
+
+var (
+	didDocumentProps = []string{"@context", "id", "alsoKnownAs",
+		"controller", "verificationMethod", "authentication",
+		"assertionMethod", "keyAgreement", "capabilityInvocation",
+		"capabilityDelegation", "service"}
+	verificationMethodProps = []string{
+		"id", "type", "controller", "publicKeyJwk", "publicKeyMultibase"}
+	serviceProps = []string{"id", "type", "serviceEndpoint"}
+)
+
+// MarshalJSON implements json.Marshaler interface
+func (doc DIDDocument) MarshalJSON() ([]byte, error) {
+	obj := make(map[string]interface{}, len(doc.Properties)+11)
+	for k, v := range doc.Properties {
+		obj[k] = v
+	}
+	for _, k := range didDocumentProps {
+		delete(obj, k)
+	}
+
+	obj["id"] = doc.ID
+	if len(doc.AlsoKnownAs) > 0 {
+		obj["alsoKnownAs"] = doc.AlsoKnownAs
+	}
+	if len(doc.VerificationMethod) > 0 {
+		obj["verificationMethod"] = doc.VerificationMethod
+	}
+	if len(doc.Service) > 0 {
+		obj["service"] = doc.Service
+	}
+
+	relationships := []struct {
+		name string
+		rels []VerificationRelationship
+	}{
+		{"authentication", doc.Authentication},
+		{"assertionMethod", doc.AssertionMethod},
+		{"keyAgreement", doc.KeyAgreement},
+		{"capabilityInvocation", doc.CapabilityInvocation},
+		{"capabilityDelegation", doc.CapabilityDelegation},
+	}
+	for _, rel := range relationships {
+		if len(rel.rels) > 0 {
+			obj[rel.name] = rel.rels
+		}
+	}
+
+	switch len(doc.Context) {
+	case 0:
+	case 1:
+		obj["@context"] = doc.Context[0]
+	default:
+		obj["@context"] = doc.Context
+	}
+
+	switch len(doc.Controller) {
+	case 0:
+	case 1:
+		obj["controller"] = doc.Controller[0]
+	default:
+		obj["controller"] = doc.Controller
+	}
+
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface. Relative DID URLs
+// (like `#key-1`) are resolved against the document's ID.
+func (doc *DIDDocument) UnmarshalJSON(in []byte) error {
+	var obj struct {
+		Context              json.RawMessage   `json:"@context"`
+		ID                   string            `json:"id"`
+		AlsoKnownAs          []string          `json:"alsoKnownAs"`
+		Controller           json.RawMessage   `json:"controller"`
+		VerificationMethod   []json.RawMessage `json:"verificationMethod"`
+		Authentication       []json.RawMessage `json:"authentication"`
+		AssertionMethod      []json.RawMessage `json:"assertionMethod"`
+		KeyAgreement         []json.RawMessage `json:"keyAgreement"`
+		CapabilityInvocation []json.RawMessage `json:"capabilityInvocation"`
+		CapabilityDelegation []json.RawMessage `json:"capabilityDelegation"`
+		Service              []json.RawMessage `json:"service"`
+	}
+	err := json.Unmarshal(in, &obj)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseDocumentDID(obj.ID)
+	if err != nil {
+		return fmt.Errorf("invalid DID document id: %w", err)
+	}
+
+	var d DIDDocument
+	d.ID = *id
+	d.AlsoKnownAs = obj.AlsoKnownAs
+
+	d.Context, err = unmarshalOneOrSet(obj.Context)
+	if err != nil {
+		return fmt.Errorf("invalid @context: %w", err)
+	}
+
+	controllers, err := unmarshalStringOrSet(obj.Controller)
+	if err != nil {
+		return fmt.Errorf("invalid controller: %w", err)
+	}
+	for _, c := range controllers {
+		controller, err := parseDIDReference(c, id)
+		if err != nil {
+			return fmt.Errorf("invalid controller: %w", err)
+		}
+		d.Controller = append(d.Controller, controller)
+	}
+
+	for _, raw := range obj.VerificationMethod {
+		var vm VerificationMethod
+		err = vm.unmarshalJSON(raw, id)
+		if err != nil {
+			return fmt.Errorf("invalid verificationMethod: %w", err)
+		}
+		d.VerificationMethod = append(d.VerificationMethod, vm)
+	}
+
+	relationships := []struct {
+		name string
+		in   []json.RawMessage
+		out  *[]VerificationRelationship
+	}{
+		{"authentication", obj.Authentication, &d.Authentication},
+		{"assertionMethod", obj.AssertionMethod, &d.AssertionMethod},
+		{"keyAgreement", obj.KeyAgreement, &d.KeyAgreement},
+		{"capabilityInvocation", obj.CapabilityInvocation,
+			&d.CapabilityInvocation},
+		{"capabilityDelegation", obj.CapabilityDelegation,
+			&d.CapabilityDelegation},
+	}
+	for _, rel := range relationships {
+		for _, raw := range rel.in {
+			var r VerificationRelationship
+			err = r.unmarshalJSON(raw, id)
+			if err != nil {
+				return fmt.Errorf("invalid %v: %w", rel.name, err)
+			}
+			*rel.out = append(*rel.out, r)
+		}
+	}
+
+	for _, raw := range obj.Service {
+		var s Service
+		err = s.unmarshalJSON(raw, id)
+		if err != nil {
+			return fmt.Errorf("invalid service: %w", err)
+		}
+		d.Service = append(d.Service, s)
+	}
+
+	d.Properties, err = unmarshalExtraProperties(in, didDocumentProps)
+	if err != nil {
+		return err
+	}
+
+	*doc = d
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler interface
+func (vm VerificationMethod) MarshalJSON() ([]byte, error) {
+	obj := make(map[string]interface{}, len(vm.Properties)+5)
+	for k, v := range vm.Properties {
+		obj[k] = v
+	}
+	obj["id"] = vm.ID
+	obj["type"] = vm.Type
+	if vm.Controller.Method != "" {
+		obj["controller"] = vm.Controller
+	} else {
+		delete(obj, "controller")
+	}
+	if vm.PublicKeyJwk != nil {
+		obj["publicKeyJwk"] = vm.PublicKeyJwk
+	} else {
+		delete(obj, "publicKeyJwk")
+	}
+	if vm.PublicKeyMultibase != "" {
+		obj["publicKeyMultibase"] = vm.PublicKeyMultibase
+	} else {
+		delete(obj, "publicKeyMultibase")
+	}
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface. The DID URLs should
+// be absolute.
+func (vm *VerificationMethod) UnmarshalJSON(in []byte) error {
+	return vm.unmarshalJSON(in, nil)
+}
+
+func (vm *VerificationMethod) unmarshalJSON(in []byte, base *DID) error {
+	var obj struct {
+		ID                 string      `json:"id"`
+		Type               string      `json:"type"`
+		Controller         string      `json:"controller"`
+		PublicKeyJwk       *JSONWebKey `json:"publicKeyJwk"`
+		PublicKeyMultibase string      `json:"publicKeyMultibase"`
+	}
+	err := json.Unmarshal(in, &obj)
+	if err != nil {
+		return err
+	}
+
+	var m VerificationMethod
+	m.ID, err = parseDIDReference(obj.ID, base)
+	if err != nil {
+		return fmt.Errorf("invalid id: %w", err)
+	}
+	if obj.Controller != "" {
+		m.Controller, err = parseDIDReference(obj.Controller, base)
+		if err != nil {
+			return fmt.Errorf("invalid controller: %w", err)
+		}
+	}
+	m.Type = obj.Type
+	m.PublicKeyJwk = obj.PublicKeyJwk
+	m.PublicKeyMultibase = obj.PublicKeyMultibase
+
+	m.Properties, err = unmarshalExtraProperties(in, verificationMethodProps)
+	if err != nil {
+		return err
+	}
+
+	*vm = m
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler interface. Referenced verification
+// method is encoded as a string, embedded one as an object.
+func (r VerificationRelationship) MarshalJSON() ([]byte, error) {
+	if r.Embedded != nil {
+		return json.Marshal(r.Embedded)
+	}
+	return json.Marshal(r.Reference)
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface. Accepts both a
+// string reference and an embedded verification method.
+func (r *VerificationRelationship) UnmarshalJSON(in []byte) error {
+	return r.unmarshalJSON(in, nil)
+}
+
+func (r *VerificationRelationship) unmarshalJSON(in []byte, base *DID) error {
+	in = bytes.TrimSpace(in)
+	if len(in) > 0 && in[0] == '{' {
+		var vm VerificationMethod
+		err := vm.unmarshalJSON(in, base)
+		if err != nil {
+			return err
+		}
+		*r = VerificationRelationship{Embedded: &vm}
+		return nil
+	}
+
+	var ref string
+	err := json.Unmarshal(in, &ref)
+	if err != nil {
+		return err
+	}
+	did, err := parseDIDReference(ref, base)
+	if err != nil {
+		return err
+	}
+	*r = VerificationRelationship{Reference: did}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler interface
+func (s Service) MarshalJSON() ([]byte, error) {
+	obj := make(map[string]interface{}, len(s.Properties)+3)
+	for k, v := range s.Properties {
+		obj[k] = v
+	}
+	obj["id"] = s.ID
+	if len(s.Type) == 1 {
+		obj["type"] = s.Type[0]
+	} else {
+		obj["type"] = s.Type
+	}
+	obj["serviceEndpoint"] = s.ServiceEndpoint
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface. The DID URLs should
+// be absolute.
+func (s *Service) UnmarshalJSON(in []byte) error {
+	return s.unmarshalJSON(in, nil)
+}
+
+func (s *Service) unmarshalJSON(in []byte, base *DID) error {
+	var obj struct {
+		ID              string          `json:"id"`
+		Type            json.RawMessage `json:"type"`
+		ServiceEndpoint ServiceEndpoint `json:"serviceEndpoint"`
+	}
+	err := json.Unmarshal(in, &obj)
+	if err != nil {
+		return err
+	}
+
+	var srv Service
+	srv.ID, err = parseDIDReference(obj.ID, base)
+	if err != nil {
+		return fmt.Errorf("invalid id: %w", err)
+	}
+	srv.Type, err = unmarshalStringOrSet(obj.Type)
+	if err != nil {
+		return fmt.Errorf("invalid type: %w", err)
+	}
+	srv.ServiceEndpoint = obj.ServiceEndpoint
+
+	srv.Properties, err = unmarshalExtraProperties(in, serviceProps)
+	if err != nil {
+		return err
+	}
+
+	*s = srv
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler interface
+func (se ServiceEndpoint) MarshalJSON() ([]byte, error) {
+	switch {
+	case se.URI != "":
+		return json.Marshal(se.URI)
+	case se.Map != nil:
+		return json.Marshal(se.Map)
+	default:
+		return json.Marshal(se.Set)
+	}
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface
+func (se *ServiceEndpoint) UnmarshalJSON(in []byte) error {
+	in = bytes.TrimSpace(in)
+	if len(in) == 0 {
+		return errors.New("empty service endpoint")
+	}
+
+	var e ServiceEndpoint
+	var err error
+	switch in[0] {
+	case '"':
+		err = json.Unmarshal(in, &e.URI)
+	case '{':
+		err = json.Unmarshal(in, &e.Map)
+	case '[':
+		err = json.Unmarshal(in, &e.Set)
+	default:
+		err = errors.New("service endpoint is not a string, map or set")
+	}
+	if err != nil {
+		return err
+	}
+
+	*se = e
+	return nil
+}
+
+// parseDIDReference parses DID URL that may be relative to the base DID
+// (begins with `/`, `?` or `#`).
+func parseDIDReference(ref string, base *DID) (DID, error) {
+	if ref != "" && (ref[0] == '/' || ref[0] == '?' || ref[0] == '#') {
+		if base == nil {
+			return DID{}, fmt.Errorf(
+				"relative DID URL %q can't be resolved without base DID",
+				ref)
+		}
+		baseDID := DID{Method: base.Method, ID: base.ID,
+			IDStrings: base.IDStrings}
+		ref = baseDID.String() + ref
+	}
+
+	did, err := parseDocumentDID(ref)
+	if err != nil {
+		return DID{}, err
+	}
+	return *did, nil
+}
+
+// parseDocumentDID parses DID or DID URL of DID document. DIDs of some
+// methods (e.g. did:web with port) are valid by DID Core grammar only, so
+// DID Core grammar is tried if the default one fails. The error of the
+// default grammar is returned if both fail.
+func parseDocumentDID(s string) (*DID, error) {
+	did, err := ParseDID(s)
+	if err == nil {
+		return did, nil
+	}
+
+	did, coreErr := ParseDID(s, WithParseMode(ParseModeCore))
+	if coreErr != nil {
+		return nil, err
+	}
+	return did, nil
+}
+
+// unmarshalOneOrSet unmarshals a JSON value that may be a single item or an
+// array of items.
+func unmarshalOneOrSet(in json.RawMessage) ([]interface{}, error) {
+	in = bytes.TrimSpace(in)
+	if len(in) == 0 || bytes.Equal(in, []byte("null")) {
+		return nil, nil
+	}
+
+	if in[0] == '[' {
+		var set []interface{}
+		err := json.Unmarshal(in, &set)
+		return set, err
+	}
+
+	var one interface{}
+	err := json.Unmarshal(in, &one)
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{one}, nil
+}
+
+// unmarshalStringOrSet unmarshals a JSON value that may be a single string
+// or an array of strings.
+func unmarshalStringOrSet(in json.RawMessage) ([]string, error) {
+	in = bytes.TrimSpace(in)
+	if len(in) == 0 || bytes.Equal(in, []byte("null")) {
+		return nil, nil
+	}
+
+	if in[0] == '[' {
+		var set []string
+		err := json.Unmarshal(in, &set)
+		return set, err
+	}
+
+	var one string
+	err := json.Unmarshal(in, &one)
+	if err != nil {
+		return nil, err
+	}
+	return []string{one}, nil
+}
+
+// unmarshalExtraProperties returns all properties of JSON object except
+// known ones. Returns nil if there are no extra properties.
+func unmarshalExtraProperties(in []byte,
+	known []string) (map[string]interface{}, error) {
+
+	var props map[string]interface{}
+	err := json.Unmarshal(in, &props)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range known {
+		delete(props, k)
+	}
+	if len(props) == 0 {
+		return nil, nil
+	}
+	return props, nil
+}
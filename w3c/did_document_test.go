@@ -0,0 +1,284 @@
+package w3c
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testDIDDocument = `{
+  "@context": [
+    "https://www.w3.org/ns/did/v1",
+    {"@vocab": "https://example.com/vocab#"}
+  ],
+  "id": "did:example:123456789abcdefghi",
+  "alsoKnownAs": ["https://example.com/user"],
+  "controller": "did:example:bcehfew7h32f32h7af3",
+  "verificationMethod": [
+    {
+      "id": "#key-1",
+      "type": "JsonWebKey2020",
+      "controller": "did:example:123456789abcdefghi",
+      "publicKeyJwk": {
+        "kty": "OKP",
+        "crv": "Ed25519",
+        "x": "VCpo2LMLhn6iWku8MKvSLg2ZAoC-nlOyPVQaO3FxVeQ"
+      }
+    },
+    {
+      "id": "did:example:123456789abcdefghi#key-2",
+      "type": "Ed25519VerificationKey2020",
+      "controller": "did:example:123456789abcdefghi",
+      "publicKeyMultibase": "z6Mkf5rGMoatrSj1f4CyvuHBeXJELe9RPdzo2PKGNCKVtZxP",
+      "revoked": "2023-01-01T00:00:00Z"
+    }
+  ],
+  "authentication": [
+    "#key-1",
+    {
+      "id": "did:example:123456789abcdefghi#key-3",
+      "type": "Ed25519VerificationKey2020",
+      "controller": "did:example:123456789abcdefghi",
+      "publicKeyMultibase": "z6MkmM42vxfqZQsv4ehtTjFFxQ4sQKS2w6WR7emozFAn5cxu"
+    }
+  ],
+  "assertionMethod": ["did:example:123456789abcdefghi#key-2"],
+  "keyAgreement": ["#key-1"],
+  "service": [
+    {
+      "id": "#linked-domain",
+      "type": "LinkedDomains",
+      "serviceEndpoint": "https://bar.example.com"
+    },
+    {
+      "id": "did:example:123456789abcdefghi#hub",
+      "type": ["IdentityHub", "Other"],
+      "serviceEndpoint": {"origins": ["https://hub.example.com/"]},
+      "priority": 1
+    },
+    {
+      "id": "did:example:123456789abcdefghi#set",
+      "type": "Set",
+      "serviceEndpoint": ["https://a.example.com", {"uri": "https://b.example.com"}]
+    }
+  ]
+}`
+
+func mustParseDID(t testing.TB, s string) DID {
+	t.Helper()
+	did, err := ParseDID(s)
+	require.NoError(t, err)
+	return *did
+}
+
+func TestDIDDocument_UnmarshalJSON(t *testing.T) {
+	var doc DIDDocument
+	err := json.Unmarshal([]byte(testDIDDocument), &doc)
+	require.NoError(t, err)
+
+	require.Equal(t, []interface{}{
+		DIDContextV1,
+		map[string]interface{}{"@vocab": "https://example.com/vocab#"},
+	}, doc.Context)
+	require.Equal(t, "did:example:123456789abcdefghi", doc.ID.String())
+	require.Equal(t, []string{"https://example.com/user"}, doc.AlsoKnownAs)
+	require.Equal(t,
+		[]DID{mustParseDID(t, "did:example:bcehfew7h32f32h7af3")},
+		doc.Controller)
+
+	require.Len(t, doc.VerificationMethod, 2)
+	vm := doc.VerificationMethod[0]
+	require.Equal(t, "did:example:123456789abcdefghi#key-1", vm.ID.String())
+	require.Equal(t, "key-1", vm.ID.Fragment)
+	require.Equal(t, "JsonWebKey2020", vm.Type)
+	require.Equal(t, &JSONWebKey{Kty: "OKP", Crv: "Ed25519",
+		X: "VCpo2LMLhn6iWku8MKvSLg2ZAoC-nlOyPVQaO3FxVeQ"}, vm.PublicKeyJwk)
+	require.Nil(t, vm.Properties)
+	vm = doc.VerificationMethod[1]
+	require.Equal(t, "z6Mkf5rGMoatrSj1f4CyvuHBeXJELe9RPdzo2PKGNCKVtZxP",
+		vm.PublicKeyMultibase)
+	require.Equal(t,
+		map[string]interface{}{"revoked": "2023-01-01T00:00:00Z"},
+		vm.Properties)
+
+	require.Len(t, doc.Authentication, 2)
+	require.Nil(t, doc.Authentication[0].Embedded)
+	require.Equal(t, "did:example:123456789abcdefghi#key-1",
+		doc.Authentication[0].Reference.String())
+	require.NotNil(t, doc.Authentication[1].Embedded)
+	require.Equal(t, "did:example:123456789abcdefghi#key-3",
+		doc.Authentication[1].Embedded.ID.String())
+	require.Equal(t, doc.Authentication[1].Embedded.ID,
+		doc.Authentication[1].ID())
+	require.Len(t, doc.AssertionMethod, 1)
+	require.Len(t, doc.KeyAgreement, 1)
+	require.Empty(t, doc.CapabilityInvocation)
+	require.Empty(t, doc.CapabilityDelegation)
+
+	require.Len(t, doc.Service, 3)
+	require.Equal(t, "did:example:123456789abcdefghi#linked-domain",
+		doc.Service[0].ID.String())
+	require.Equal(t, []string{"LinkedDomains"}, doc.Service[0].Type)
+	require.Equal(t, ServiceEndpoint{URI: "https://bar.example.com"},
+		doc.Service[0].ServiceEndpoint)
+	require.Equal(t, []string{"IdentityHub", "Other"}, doc.Service[1].Type)
+	require.Equal(t, map[string]interface{}{
+		"origins": []interface{}{"https://hub.example.com/"}},
+		doc.Service[1].ServiceEndpoint.Map)
+	require.Equal(t, map[string]interface{}{"priority": float64(1)},
+		doc.Service[1].Properties)
+	require.Equal(t, []ServiceEndpoint{
+		{URI: "https://a.example.com"},
+		{Map: map[string]interface{}{"uri": "https://b.example.com"}},
+	}, doc.Service[2].ServiceEndpoint.Set)
+}
+
+func TestDIDDocument_MarshalJSON(t *testing.T) {
+	var doc DIDDocument
+	err := json.Unmarshal([]byte(testDIDDocument), &doc)
+	require.NoError(t, err)
+
+	docBytes, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	want := `{
+  "@context": [
+    "https://www.w3.org/ns/did/v1",
+    {"@vocab": "https://example.com/vocab#"}
+  ],
+  "id": "did:example:123456789abcdefghi",
+  "alsoKnownAs": ["https://example.com/user"],
+  "controller": "did:example:bcehfew7h32f32h7af3",
+  "verificationMethod": [
+    {
+      "id": "did:example:123456789abcdefghi#key-1",
+      "type": "JsonWebKey2020",
+      "controller": "did:example:123456789abcdefghi",
+      "publicKeyJwk": {
+        "kty": "OKP",
+        "crv": "Ed25519",
+        "x": "VCpo2LMLhn6iWku8MKvSLg2ZAoC-nlOyPVQaO3FxVeQ"
+      }
+    },
+    {
+      "id": "did:example:123456789abcdefghi#key-2",
+      "type": "Ed25519VerificationKey2020",
+      "controller": "did:example:123456789abcdefghi",
+      "publicKeyMultibase": "z6Mkf5rGMoatrSj1f4CyvuHBeXJELe9RPdzo2PKGNCKVtZxP",
+      "revoked": "2023-01-01T00:00:00Z"
+    }
+  ],
+  "authentication": [
+    "did:example:123456789abcdefghi#key-1",
+    {
+      "id": "did:example:123456789abcdefghi#key-3",
+      "type": "Ed25519VerificationKey2020",
+      "controller": "did:example:123456789abcdefghi",
+      "publicKeyMultibase": "z6MkmM42vxfqZQsv4ehtTjFFxQ4sQKS2w6WR7emozFAn5cxu"
+    }
+  ],
+  "assertionMethod": ["did:example:123456789abcdefghi#key-2"],
+  "keyAgreement": ["did:example:123456789abcdefghi#key-1"],
+  "service": [
+    {
+      "id": "did:example:123456789abcdefghi#linked-domain",
+      "type": "LinkedDomains",
+      "serviceEndpoint": "https://bar.example.com"
+    },
+    {
+      "id": "did:example:123456789abcdefghi#hub",
+      "type": ["IdentityHub", "Other"],
+      "serviceEndpoint": {"origins": ["https://hub.example.com/"]},
+      "priority": 1
+    },
+    {
+      "id": "did:example:123456789abcdefghi#set",
+      "type": "Set",
+      "serviceEndpoint": ["https://a.example.com", {"uri": "https://b.example.com"}]
+    }
+  ]
+}`
+	require.JSONEq(t, want, string(docBytes))
+
+	var doc2 DIDDocument
+	err = json.Unmarshal(docBytes, &doc2)
+	require.NoError(t, err)
+	require.Equal(t, doc, doc2)
+}
+
+func TestDIDDocument_MarshalJSON_Minimal(t *testing.T) {
+	doc := DIDDocument{
+		Context: []interface{}{DIDContextV1},
+		ID:      mustParseDID(t, "did:example:123"),
+	}
+	docBytes, err := json.Marshal(doc)
+	require.NoError(t, err)
+	require.JSONEq(t,
+		`{"@context":"https://www.w3.org/ns/did/v1","id":"did:example:123"}`,
+		string(docBytes))
+}
+
+func TestDIDDocument_MarshalJSON_Properties(t *testing.T) {
+	in := `{
+  "@context": "https://www.w3.org/ns/did/v1",
+  "id": "did:example:123",
+  "deactivated": true,
+  "extension": {"version": 2}
+}`
+	var doc DIDDocument
+	err := json.Unmarshal([]byte(in), &doc)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"deactivated": true,
+		"extension":   map[string]interface{}{"version": float64(2)},
+	}, doc.Properties)
+
+	docBytes, err := json.Marshal(doc)
+	require.NoError(t, err)
+	require.JSONEq(t, in, string(docBytes))
+
+	// known properties are not overwritten by Properties
+	doc.Properties["id"] = "did:example:456"
+	docBytes, err = json.Marshal(doc)
+	require.NoError(t, err)
+	require.JSONEq(t, in, string(docBytes))
+}
+
+func TestVerificationMethod_MarshalJSON_NoController(t *testing.T) {
+	vm := VerificationMethod{
+		ID:                 mustParseDID(t, "did:example:123#key-1"),
+		Type:               "Ed25519VerificationKey2020",
+		PublicKeyMultibase: "z6MkmM42vxfqZQsv4ehtTjFFxQ4sQKS2w6WR7emozFAn5cxu",
+	}
+	vmBytes, err := json.Marshal(vm)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+  "id": "did:example:123#key-1",
+  "type": "Ed25519VerificationKey2020",
+  "publicKeyMultibase": "z6MkmM42vxfqZQsv4ehtTjFFxQ4sQKS2w6WR7emozFAn5cxu"
+}`, string(vmBytes))
+
+	var vm2 VerificationMethod
+	err = json.Unmarshal(vmBytes, &vm2)
+	require.NoError(t, err)
+	require.Equal(t, vm, vm2)
+}
+
+func TestVerificationRelationship_UnmarshalJSON_RelativeWithoutBase(t *testing.T) {
+	var r VerificationRelationship
+	err := json.Unmarshal([]byte(`"#key-1"`), &r)
+	require.EqualError(t, err,
+		`relative DID URL "#key-1" can't be resolved without base DID`)
+
+	err = json.Unmarshal([]byte(`"did:example:123#key-1"`), &r)
+	require.NoError(t, err)
+	require.Equal(t, mustParseDID(t, "did:example:123#key-1"), r.ID())
+}
+
+func TestDIDDocument_UnmarshalJSON_InvalidID(t *testing.T) {
+	var doc DIDDocument
+	err := json.Unmarshal([]byte(`{"id": "example:123"}`), &doc)
+	require.EqualError(t, err,
+		"invalid DID document id: input does not begin with 'did:' prefix")
+}
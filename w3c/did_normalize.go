@@ -0,0 +1,115 @@
+package w3c
+
+import "strings"
+
+// Normalize returns a copy of the DID in the normal form, so that
+// equivalent DIDs have the same String() representation:
+//
+//   - ID is set from IDStrings when empty, IDStrings are set from ID. When
+//     both are set, ID is used as String() does;
+//   - Path is set from PathSegments when empty, PathSegments are set from
+//     Path. When both are set, Path is used as String() does;
+//   - hex digits of percent-encoded octets are uppercased and the octets of
+//     unreserved characters are decoded, as per RFC 3986 section 6.2.2.
+//
+// Method is not changed, as DID methods are case-sensitive.
+func (d *DID) Normalize() *DID {
+	n := &DID{Method: d.Method}
+
+	n.ID = d.ID
+	if n.ID == "" {
+		n.ID = strings.Join(d.IDStrings, ":")
+	}
+	n.ID = normalizePctEncoding(n.ID, isNotValidCoreIDChar)
+	if n.ID != "" {
+		n.IDStrings = strings.Split(n.ID, ":")
+	}
+
+	if len(d.Params) > 0 {
+		n.Params = make([]Param, len(d.Params))
+		for i, p := range d.Params {
+			n.Params[i] = Param{
+				Name:  normalizePctEncoding(p.Name, isNotValidParamChar),
+				Value: normalizePctEncoding(p.Value, isNotValidParamChar),
+			}
+		}
+	}
+
+	n.Path = d.Path
+	if n.Path == "" {
+		n.Path = strings.Join(d.PathSegments, "/")
+	}
+	n.Path = normalizePctEncoding(n.Path, isNotValidPathChar)
+	if n.Path != "" {
+		n.PathSegments = strings.Split(n.Path, "/")
+	}
+
+	n.Query = normalizePctEncoding(d.Query, isNotValidQueryOrFragmentChar)
+	n.Fragment = normalizePctEncoding(d.Fragment,
+		isNotValidQueryOrFragmentChar)
+
+	return n
+}
+
+// Equal returns true if the DIDs are equivalent, that is their normalized
+// forms are the same. DIDs that can't be encoded to string (e.g. with empty
+// Method or ID) are never equal.
+func (d *DID) Equal(other *DID) bool {
+	if d == nil || other == nil {
+		return false
+	}
+	s := d.Normalize().String()
+	return s != "" && s == other.Normalize().String()
+}
+
+// Base returns the bare DID without params, path, query and fragment.
+//
+//	did:example:123;service=agent/path?query#fragment -> did:example:123
+func (d *DID) Base() *DID {
+	b := &DID{Method: d.Method, ID: d.ID}
+	if d.IDStrings != nil {
+		b.IDStrings = append([]string(nil), d.IDStrings...)
+	}
+	return b
+}
+
+// normalizePctEncoding uppercases hex digits of pct-encoded octets and
+// decodes the octets of unreserved characters that are valid in the
+// component. Malformed pct-encoded octets are kept as is.
+func normalizePctEncoding(s string, isNotValid func(byte) bool) string {
+	if strings.IndexByte(s, '%') == -1 {
+		return s
+	}
+
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '%' || i+2 >= len(s) || isNotHexDigit(s[i+1]) ||
+			isNotHexDigit(s[i+2]) {
+
+			buf = append(buf, c)
+			continue
+		}
+
+		decoded := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if !isNotUnreserved(decoded) && !isNotValid(decoded) {
+			buf = append(buf, decoded)
+		} else {
+			buf = appendEscaped(buf, decoded)
+		}
+		i += 2
+	}
+	return string(buf)
+}
+
+// unhex returns the value of a hex digit
+func unhex(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	default:
+		return c - '0'
+	}
+}
@@ -0,0 +1,105 @@
+package w3c
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDID_Normalize(t *testing.T) {
+	testCases := []struct {
+		name string
+		did  DID
+		want string
+	}{
+		{
+			name: "pct-encoding case",
+			did: DID{Method: "web", ID: "example.com%3a8080",
+				Query: "a=%2f%2F", Fragment: "key%2b1"},
+			want: "did:web:example.com%3A8080?a=%2F%2F#key%2B1",
+		},
+		{
+			name: "decode unreserved",
+			did: DID{Method: "example", ID: "a%2Db%5f%7E",
+				Path: "p%7e", Fragment: "%41"},
+			want: "did:example:a-b_%7E/p~#A",
+		},
+		{
+			name: "sub-delims are not decoded",
+			did:  DID{Method: "example", ID: "123", Query: "a=%26%3D"},
+			want: "did:example:123?a=%26%3D",
+		},
+		{
+			name: "IDStrings only",
+			did:  DID{Method: "example", IDStrings: []string{"net", "123"}},
+			want: "did:example:net:123",
+		},
+		{
+			name: "PathSegments only",
+			did: DID{Method: "example", ID: "123",
+				PathSegments: []string{"a", "b%2f"}},
+			want: "did:example:123/a/b%2F",
+		},
+		{
+			name: "malformed pct-encoding is kept",
+			did:  DID{Method: "example", ID: "123", Fragment: "a%2"},
+			want: "did:example:123#a%2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := tc.did.Normalize()
+			require.Equal(t, tc.want, n.String())
+			// normalization is idempotent
+			require.Equal(t, n, n.Normalize())
+		})
+	}
+}
+
+func TestDID_Normalize_Consistency(t *testing.T) {
+	d := DID{Method: "example", ID: "net:123",
+		IDStrings: []string{"other"}, Path: "a/b",
+		PathSegments: []string{"c"}}
+	n := d.Normalize()
+	require.Equal(t, "net:123", n.ID)
+	require.Equal(t, []string{"net", "123"}, n.IDStrings)
+	require.Equal(t, "a/b", n.Path)
+	require.Equal(t, []string{"a", "b"}, n.PathSegments)
+
+	// the source DID is not modified
+	require.Equal(t, []string{"other"}, d.IDStrings)
+	require.Equal(t, []string{"c"}, d.PathSegments)
+}
+
+func TestDID_Equal(t *testing.T) {
+	parsed := mustParseDID(t, "did:example:net:123/a/b?x=%2f#k")
+	built := DID{Method: "example", IDStrings: []string{"net", "123"},
+		PathSegments: []string{"a", "b"}, Query: "x=%2F", Fragment: "k"}
+	require.True(t, parsed.Equal(&built))
+	require.True(t, built.Equal(&parsed))
+
+	other := built
+	other.Fragment = "K"
+	require.False(t, parsed.Equal(&other))
+
+	other = built
+	other.Method = "Example"
+	require.False(t, parsed.Equal(&other))
+
+	invalid := DID{ID: "123"}
+	require.False(t, invalid.Equal(&invalid))
+	require.False(t, parsed.Equal(nil))
+}
+
+func TestDID_Base(t *testing.T) {
+	d := mustParseDID(t, "did:example:net:123;service=agent/path?query#frag")
+	b := d.Base()
+	require.Equal(t, "did:example:net:123", b.String())
+	require.False(t, b.IsURL())
+	require.Equal(t, []string{"net", "123"}, b.IDStrings)
+
+	// the base does not share IDStrings with the source DID
+	b.IDStrings[0] = "x"
+	require.Equal(t, "net", d.IDStrings[0])
+}
@@ -0,0 +1,278 @@
+package w3c
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Names of the DID parameters defined by DID Core spec
+// https://www.w3.org/TR/did-core/#did-parameters
+const (
+	ParamService     = "service"
+	ParamRelativeRef = "relativeRef"
+	ParamVersionID   = "versionId"
+	ParamVersionTime = "versionTime"
+	ParamHashLink    = "hl"
+)
+
+// queryParam is a decoded name-value pair of DID URL query
+type queryParam struct {
+	name  string
+	value string
+}
+
+// parseQuery splits the query into decoded name-value pairs keeping their
+// order. Unlike url.ParseQuery, `+` is not decoded as a space, as DID URL
+// query follows RFC 3986.
+func parseQuery(query string) ([]queryParam, error) {
+	var params []queryParam
+	for query != "" {
+		var pair string
+		pair, query, _ = cut(query, "&")
+		if pair == "" {
+			continue
+		}
+		name, value, _ := cut(pair, "=")
+		name, err := url.PathUnescape(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err = url.PathUnescape(value)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, queryParam{name: name, value: value})
+	}
+	return params, nil
+}
+
+// encodeQuery encodes name-value pairs to the query string
+func encodeQuery(params []queryParam) string {
+	var buf strings.Builder
+	for i, p := range params {
+		if i > 0 {
+			buf.WriteByte('&')
+		}
+		buf.WriteString(encodeQueryParam(p.name, p.value))
+	}
+	return buf.String()
+}
+
+// encodeQueryParam encodes query parameter name=value. It is shared by the
+// query setters and DIDURLBuilder, so the parameter is encoded the same way
+// by both, e.g. `versionTime=2021-05-10T17:00:00Z`.
+func encodeQueryParam(name, value string) string {
+	return escape(name, isNotValidQueryParamChar) + "=" +
+		escape(value, isNotValidQueryParamChar)
+}
+
+// isNotValidQueryParamChar returns true if a byte is not allowed in a name
+// or value of query parameter. Parameter delimiters `&` and `=` and `+`,
+// that is often decoded as a space, are not allowed.
+func isNotValidQueryParamChar(char byte) bool {
+	return isNotValidQueryOrFragmentChar(char) ||
+		char == '&' || char == '=' || char == '+'
+}
+
+const upperHex = "0123456789ABCDEF"
+
+// escape percent-encodes `%` and all bytes for which isNotValid returns
+// true. s is returned as is if nothing is encoded.
+func escape(s string, isNotValid func(byte) bool) string {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' || isNotValid(s[i]) {
+			n++
+		}
+	}
+	if n == 0 {
+		return s
+	}
+
+	buf := make([]byte, 0, len(s)+2*n)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '%' || isNotValid(c) {
+			buf = appendEscaped(buf, c)
+		} else {
+			buf = append(buf, c)
+		}
+	}
+	return string(buf)
+}
+
+// appendEscaped appends pct-encoded byte c to buf using upper case hex
+// digits
+func appendEscaped(buf []byte, c byte) []byte {
+	return append(buf, '%', upperHex[c>>4], upperHex[c&15])
+}
+
+// QueryValues returns decoded DID URL query parameters.
+func (d *DID) QueryValues() (url.Values, error) {
+	params, err := parseQuery(d.Query)
+	if err != nil {
+		return nil, err
+	}
+	values := make(url.Values, len(params))
+	for _, p := range params {
+		values[p.name] = append(values[p.name], p.value)
+	}
+	return values, nil
+}
+
+// SetQueryValues replaces DID URL query with encoded values. Parameters are
+// sorted by name.
+func (d *DID) SetQueryValues(values url.Values) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var params []queryParam
+	for _, name := range names {
+		for _, value := range values[name] {
+			params = append(params, queryParam{name: name, value: value})
+		}
+	}
+	d.Query = encodeQuery(params)
+}
+
+// QueryParam returns the first value of the DID URL query parameter. Returns
+// empty string if there is no such parameter or the query is malformed.
+func (d *DID) QueryParam(name string) string {
+	params, err := parseQuery(d.Query)
+	if err != nil {
+		return ""
+	}
+	for _, p := range params {
+		if p.name == name {
+			return p.value
+		}
+	}
+	return ""
+}
+
+// SetQueryParam sets the DID URL query parameter to value, replacing all
+// existing values of the parameter. Other parameters keep their order. The
+// query is re-encoded, malformed query is discarded.
+func (d *DID) SetQueryParam(name, value string) {
+	params, _ := parseQuery(d.Query)
+
+	found := false
+	i := 0
+	for _, p := range params {
+		if p.name == name {
+			if found {
+				continue
+			}
+			found = true
+			p.value = value
+		}
+		params[i] = p
+		i++
+	}
+	params = params[:i]
+	if !found {
+		params = append(params, queryParam{name: name, value: value})
+	}
+
+	d.Query = encodeQuery(params)
+}
+
+// DelQueryParam removes all values of the DID URL query parameter. The
+// query is re-encoded, malformed query is discarded.
+func (d *DID) DelQueryParam(name string) {
+	params, _ := parseQuery(d.Query)
+
+	i := 0
+	for _, p := range params {
+		if p.name != name {
+			params[i] = p
+			i++
+		}
+	}
+
+	d.Query = encodeQuery(params[:i])
+}
+
+// Service returns `service` DID parameter
+func (d *DID) Service() string {
+	return d.QueryParam(ParamService)
+}
+
+// SetService sets `service` DID parameter
+func (d *DID) SetService(service string) {
+	d.SetQueryParam(ParamService, service)
+}
+
+// RelativeRef returns `relativeRef` DID parameter
+func (d *DID) RelativeRef() string {
+	return d.QueryParam(ParamRelativeRef)
+}
+
+// SetRelativeRef sets `relativeRef` DID parameter
+func (d *DID) SetRelativeRef(ref string) {
+	d.SetQueryParam(ParamRelativeRef, ref)
+}
+
+// VersionID returns `versionId` DID parameter
+func (d *DID) VersionID() string {
+	return d.QueryParam(ParamVersionID)
+}
+
+// SetVersionID sets `versionId` DID parameter
+func (d *DID) SetVersionID(versionID string) {
+	d.SetQueryParam(ParamVersionID, versionID)
+}
+
+// VersionTime returns `versionTime` DID parameter. The flag is false if the
+// parameter is not set.
+func (d *DID) VersionTime() (time.Time, bool, error) {
+	v := d.QueryParam(ParamVersionTime)
+	if v == "" {
+		return time.Time{}, false, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return t, true, nil
+}
+
+// SetVersionTime sets `versionTime` DID parameter. The time is normalized to
+// UTC without sub-second precision as required by DID Core spec.
+func (d *DID) SetVersionTime(t time.Time) {
+	d.SetQueryParam(ParamVersionTime,
+		t.UTC().Truncate(time.Second).Format(time.RFC3339))
+}
+
+// HashLink returns `hl` DID parameter
+func (d *DID) HashLink() string {
+	return d.QueryParam(ParamHashLink)
+}
+
+// SetHashLink sets `hl` DID parameter
+func (d *DID) SetHashLink(hl string) {
+	d.SetQueryParam(ParamHashLink, hl)
+}
+
+// isNotUnreserved returns true if a byte is not unreserved
+// from the grammar:
+//
+//	unreserved = ALPHA / DIGIT / "-" / "." / "_" / "~"
+func isNotUnreserved(char byte) bool {
+	return isNotAlpha(char) && isNotDigit(char) &&
+		char != '-' && char != '.' && char != '_' && char != '~'
+}
+
+// cut slices s around the first instance of sep (strings.Cut is not
+// available in all supported Go versions)
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
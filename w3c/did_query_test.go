@@ -0,0 +1,100 @@
+package w3c
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDID_QueryParams(t *testing.T) {
+	d := mustParseDID(t,
+		"did:example:123?service=files&relativeRef=%2Fresume.pdf&foo=a+b&foo=c")
+
+	require.Equal(t, "files", d.Service())
+	require.Equal(t, "/resume.pdf", d.RelativeRef())
+	require.Equal(t, "", d.VersionID())
+	require.Equal(t, "a+b", d.QueryParam("foo"))
+
+	values, err := d.QueryValues()
+	require.NoError(t, err)
+	require.Equal(t, url.Values{
+		"service":     {"files"},
+		"relativeRef": {"/resume.pdf"},
+		"foo":         {"a+b", "c"},
+	}, values)
+
+	d.SetService("agent one")
+	require.Equal(t,
+		"did:example:123?service=agent%20one&relativeRef=/resume.pdf&foo=a%2Bb&foo=c",
+		d.String())
+	require.Equal(t, "agent one", d.Service())
+
+	d.SetQueryParam("foo", "d")
+	require.Equal(t,
+		"did:example:123?service=agent%20one&relativeRef=/resume.pdf&foo=d",
+		d.String())
+
+	d.DelQueryParam("relativeRef")
+	d.SetVersionID("1")
+	d.SetHashLink("zQmWvQxTqbG2Z9HPJgG57jjwR154cKhbtJenbyYTWkjgF3e")
+	require.Equal(t,
+		"did:example:123?service=agent%20one&foo=d&versionId=1&hl=zQmWvQxTqbG2Z9HPJgG57jjwR154cKhbtJenbyYTWkjgF3e",
+		d.String())
+	require.Equal(t, "1", d.VersionID())
+	require.Equal(t, "zQmWvQxTqbG2Z9HPJgG57jjwR154cKhbtJenbyYTWkjgF3e",
+		d.HashLink())
+
+	// re-encoded DID URL is parsed back to the same DID
+	d2 := mustParseDID(t, d.String())
+	require.Equal(t, d.Query, d2.Query)
+}
+
+func TestDID_VersionTime(t *testing.T) {
+	d := mustParseDID(t, "did:example:123")
+	_, ok, err := d.VersionTime()
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	tm := time.Date(2021, 5, 10, 19, 0, 0, 123, time.FixedZone("", 2*3600))
+	d.SetVersionTime(tm)
+	require.Equal(t, "did:example:123?versionTime=2021-05-10T17:00:00Z",
+		d.String())
+
+	// the builder encodes the parameter the same way
+	s, err := NewDIDURL(mustParseDID(t, "did:example:123")).
+		WithQuery("versionTime", "2021-05-10T17:00:00Z").BuildString()
+	require.NoError(t, err)
+	require.Equal(t, d.String(), s)
+
+	d2 := mustParseDID(t, d.String())
+	tm2, ok, err := d2.VersionTime()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, tm.Truncate(time.Second).Equal(tm2))
+
+	d2.SetQueryParam(ParamVersionTime, "yesterday")
+	_, _, err = d2.VersionTime()
+	require.Error(t, err)
+}
+
+func TestDID_SetQueryValues(t *testing.T) {
+	d := mustParseDID(t, "did:example:123?a=1#key-1")
+	d.SetQueryValues(url.Values{
+		"service": {"files"},
+		"a":       {"x y", "z"},
+	})
+	require.Equal(t, "did:example:123?a=x%20y&a=z&service=files#key-1",
+		d.String())
+}
+
+func TestDID_QueryValues_Malformed(t *testing.T) {
+	d := DID{Method: "example", ID: "123", Query: "service=%zz"}
+	_, err := d.QueryValues()
+	require.Error(t, err)
+	require.Equal(t, "", d.Service())
+
+	d.SetService("files")
+	require.Equal(t, "did:example:123?service=files", d.String())
+}
@@ -0,0 +1,187 @@
+package w3c
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DIDURLBuilder builds DID URL from unencoded components. Components are
+// percent-encoded and validated against the DID URL grammar. The first
+// error stops the building and is returned by Build.
+//
+//	didURL, err := NewDIDURL(did).
+//		WithPath("files", "resume.pdf").
+//		WithQuery("versionId", "1").
+//		WithFragment("key-1").
+//		Build()
+type DIDURLBuilder struct {
+	did DID
+	err error
+}
+
+// NewDIDURL creates new DIDURLBuilder that extends did. The did must have
+// Method and ID or IDStrings. Path, query and fragment of did are kept.
+func NewDIDURL(did DID) *DIDURLBuilder {
+	b := &DIDURLBuilder{did: did.Clone()}
+	b.err = b.initBase()
+	return b
+}
+
+// WithPath appends path segments. Each segment is percent-encoded, so it
+// may contain `/` and other reserved characters. Segments must be
+// non-empty.
+func (b *DIDURLBuilder) WithPath(segments ...string) *DIDURLBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	for _, segment := range segments {
+		if segment == "" {
+			b.err = fmt.Errorf("%w: path segment is empty", ErrInvalidDIDURL)
+			return b
+		}
+		b.did.PathSegments = append(b.did.PathSegments,
+			escape(segment, isNotValidPathChar))
+	}
+	b.did.Path = strings.Join(b.did.PathSegments, "/")
+	return b
+}
+
+// WithQuery appends query parameter name=value. Both name and value are
+// percent-encoded. Name must be non-empty.
+func (b *DIDURLBuilder) WithQuery(name, value string) *DIDURLBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	if name == "" {
+		b.err = fmt.Errorf("%w: query parameter name is empty",
+			ErrInvalidDIDURL)
+		return b
+	}
+
+	param := encodeQueryParam(name, value)
+	if b.did.Query == "" {
+		b.did.Query = param
+	} else {
+		b.did.Query += "&" + param
+	}
+	return b
+}
+
+// WithFragment sets the fragment. The fragment is percent-encoded.
+func (b *DIDURLBuilder) WithFragment(fragment string) *DIDURLBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	b.did.Fragment = escape(fragment, isNotValidQueryOrFragmentChar)
+	return b
+}
+
+// Build returns built DID URL or the first error occurred.
+func (b *DIDURLBuilder) Build() (*DID, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	err := validateComponent("path", b.did.Path, func(c byte) bool {
+		return isNotValidPathChar(c) && c != '/'
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = validateComponent("query", b.did.Query,
+		isNotValidQueryOrFragmentChar)
+	if err != nil {
+		return nil, err
+	}
+	err = validateComponent("fragment", b.did.Fragment,
+		isNotValidQueryOrFragmentChar)
+	if err != nil {
+		return nil, err
+	}
+
+	did := b.did.Clone()
+	return &did, nil
+}
+
+// BuildString returns built DID URL string or the first error occurred.
+func (b *DIDURLBuilder) BuildString() (string, error) {
+	did, err := b.Build()
+	if err != nil {
+		return "", err
+	}
+	return did.String(), nil
+}
+
+// initBase validates method, method-specific-id and params of the DID and
+// makes ID and IDStrings, Path and PathSegments consistent.
+func (b *DIDURLBuilder) initBase() error {
+	d := &b.did
+
+	if d.Method == "" {
+		return fmt.Errorf("%w: method is empty", ErrInvalidDIDURL)
+	}
+	for i := 0; i < len(d.Method); i++ {
+		if isNotDigit(d.Method[i]) && isNotSmallLetter(d.Method[i]) {
+			return fmt.Errorf("%w: method has invalid character %q",
+				ErrInvalidDIDURL, d.Method[i])
+		}
+	}
+
+	if d.ID == "" {
+		d.ID = strings.Join(d.IDStrings, ":")
+	}
+	if d.ID == "" {
+		return fmt.Errorf("%w: method-specific-id is empty",
+			ErrInvalidDIDURL)
+	}
+	baseDID, err := ParseDID("did:"+d.Method+":"+d.ID,
+		WithParseMode(ParseModeCore))
+	if err != nil || baseDID.IsURL() {
+		return fmt.Errorf("%w: invalid method-specific-id %q",
+			ErrInvalidDIDURL, d.ID)
+	}
+	d.IDStrings = baseDID.IDStrings
+
+	for _, p := range d.Params {
+		if p.Name == "" {
+			return fmt.Errorf("%w: param name is empty", ErrInvalidDIDURL)
+		}
+		err = validateComponent("param", p.Name+p.Value, isNotValidParamChar)
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.Path == "" && len(d.PathSegments) > 0 {
+		d.Path = strings.Join(d.PathSegments, "/")
+	} else if d.Path != "" && len(d.PathSegments) == 0 {
+		d.PathSegments = strings.Split(d.Path, "/")
+	}
+
+	return nil
+}
+
+// validateComponent checks that all bytes of s are valid or pct-encoded
+func validateComponent(name, s string, isNotValid func(byte) bool) error {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '%' {
+			if i+2 >= len(s) || isNotHexDigit(s[i+1]) ||
+				isNotHexDigit(s[i+2]) {
+
+				return fmt.Errorf("%w: %% is not followed by 2 hex digits "+
+					"in %v", ErrInvalidDIDURL, name)
+			}
+			i += 2
+			continue
+		}
+		if isNotValid(c) {
+			return fmt.Errorf("%w: character is not allowed in %v - %c",
+				ErrInvalidDIDURL, name, c)
+		}
+	}
+	return nil
+}
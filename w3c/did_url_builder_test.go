@@ -0,0 +1,108 @@
+package w3c
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDIDURLBuilder(t *testing.T) {
+	did := mustParseDID(t, "did:example:123")
+
+	didURL, err := NewDIDURL(did).
+		WithPath("files", "a/b c.pdf").
+		WithQuery("service", "agent one").
+		WithQuery("relativeRef", "/x?y=1&z").
+		WithFragment("key 1#2").
+		Build()
+	require.NoError(t, err)
+	require.Equal(t,
+		"did:example:123/files/a%2Fb%20c.pdf"+
+			"?service=agent%20one&relativeRef=/x?y%3D1%26z#key%201%232",
+		didURL.String())
+	require.Equal(t, []string{"files", "a%2Fb%20c.pdf"}, didURL.PathSegments)
+	require.Equal(t, "agent one", didURL.Service())
+	require.Equal(t, "/x?y=1&z", didURL.RelativeRef())
+
+	// built DID URL is parsed back to the same components
+	parsed := mustParseDID(t, didURL.String())
+	require.Equal(t, didURL.Path, parsed.Path)
+	require.Equal(t, didURL.Query, parsed.Query)
+	require.Equal(t, didURL.Fragment, parsed.Fragment)
+
+	// the source DID is not modified
+	require.Equal(t, "did:example:123", did.String())
+}
+
+func TestDIDURLBuilder_ExtendsDIDURL(t *testing.T) {
+	did := DID{Method: "example", IDStrings: []string{"net", "123"},
+		Path: "a", Query: "x=1"}
+
+	s, err := NewDIDURL(did).WithPath("b").WithQuery("y", "2").BuildString()
+	require.NoError(t, err)
+	require.Equal(t, "did:example:net:123/a/b?x=1&y=2", s)
+
+	s, err = NewDIDURL(did).WithFragment("").BuildString()
+	require.NoError(t, err)
+	require.Equal(t, "did:example:net:123/a?x=1", s)
+}
+
+func TestDIDURLBuilder_Errors(t *testing.T) {
+	testCases := []struct {
+		name string
+		b    *DIDURLBuilder
+	}{
+		{"empty method",
+			NewDIDURL(DID{ID: "123"})},
+		{"invalid method",
+			NewDIDURL(DID{Method: "Example", ID: "123"})},
+		{"empty id",
+			NewDIDURL(DID{Method: "example"})},
+		{"invalid id",
+			NewDIDURL(DID{Method: "example", ID: "12 3"})},
+		{"id ends with colon",
+			NewDIDURL(DID{Method: "example", ID: "123:"})},
+		{"empty param name",
+			NewDIDURL(DID{Method: "example", ID: "123",
+				Params: []Param{{Value: "v"}}})},
+		{"invalid param",
+			NewDIDURL(DID{Method: "example", ID: "123",
+				Params: []Param{{Name: "a", Value: "%zz"}}})},
+		{"invalid base query",
+			NewDIDURL(DID{Method: "example", ID: "123", Query: "a=%"})},
+		{"invalid base path",
+			NewDIDURL(DID{Method: "example", ID: "123", Path: "a b"})},
+		{"empty path segment",
+			NewDIDURL(DID{Method: "example", ID: "123"}).WithPath("a", "")},
+		{"empty query name",
+			NewDIDURL(DID{Method: "example", ID: "123"}).WithQuery("", "1")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// the error is kept by following calls
+			b := tc.b.WithPath("p").WithQuery("q", "1").WithFragment("f")
+			did, err := b.Build()
+			require.True(t, errors.Is(err, ErrInvalidDIDURL), err)
+			require.Nil(t, did)
+
+			s, err := b.BuildString()
+			require.Error(t, err)
+			require.Equal(t, "", s)
+		})
+	}
+}
+
+func TestDID_Clone(t *testing.T) {
+	did := mustParseDID(t, "did:example:net:123;p=1/a/b?x=1#k")
+	c := did.Clone()
+	require.Equal(t, did, c)
+
+	c.IDStrings[0] = "other"
+	c.Params[0].Value = "2"
+	c.PathSegments[0] = "z"
+	require.Equal(t, []string{"net", "123"}, did.IDStrings)
+	require.Equal(t, []Param{{Name: "p", Value: "1"}}, did.Params)
+	require.Equal(t, []string{"a", "b"}, did.PathSegments)
+}
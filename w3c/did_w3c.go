@@ -76,16 +76,77 @@ type DID struct {
 	Fragment string
 }
 
+// ParseMode selects the DID grammar used by ParseDID
+type ParseMode uint8
+
+const (
+	// ParseModeLegacy follows the grammar of the W3C CCG DID spec draft:
+	// idchar is ALPHA / DIGIT / "." / "-", DID URL may have `;param`s.
+	// It is the default mode.
+	ParseModeLegacy ParseMode = iota
+	// ParseModeCore follows the DID Core 1.0 ABNF
+	// https://www.w3.org/TR/did-core/#did-syntax
+	// https://www.w3.org/TR/did-core/#did-url-syntax
+	//
+	//	did                = "did:" method-name ":" method-specific-id
+	//	method-specific-id = *( *idchar ":" ) 1*idchar
+	//	idchar             = ALPHA / DIGIT / "." / "-" / "_" / pct-encoded
+	//	did-url            = did path-abempty [ "?" query ] [ "#" fragment ]
+	ParseModeCore
+)
+
+// ParseOption configures ParseDID
+type ParseOption func(*parseOptions)
+
+type parseOptions struct {
+	mode ParseMode
+}
+
+// WithParseMode sets the DID grammar used by ParseDID
+func WithParseMode(mode ParseMode) ParseOption {
+	return func(o *parseOptions) {
+		o.mode = mode
+	}
+}
+
+// applyParseOptions returns options set by opts. It is a separate function,
+// so options escape to the heap only when they are passed to ParseDID.
+func applyParseOptions(opts []ParseOption) parseOptions {
+	var o parseOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
 // the parsers internal state
 type parser struct {
-	input        string // input to the parser
-	currentIndex int    // index in the input which the parser is currently processing
-	out          *DID   // the output DID that the parser will assemble as it steps through its state machine
-	err          error  // an error in the parser state machine
+	input        string    // input to the parser
+	currentIndex int       // index in the input which the parser is currently processing
+	out          *DID      // the output DID that the parser will assemble as it steps through its state machine
+	err          error     // an error in the parser state machine
+	mode         ParseMode // grammar the parser follows
+	idEnd        int       // index in the input after the last idstring
+	pathStart    int       // index in the input of the first path segment
+	pathEnd      int       // index in the input after the last path segment
 }
 
-// a step in the parser state machine that returns the next step
-type parserStep func() parserStep
+// a state of the parser state machine, each state is handled by a parser
+// step that returns the next state
+type parserState uint8
+
+const (
+	stateDone parserState = iota
+	stateCheckLength
+	stateScheme
+	stateMethod
+	stateID
+	stateParamName
+	stateParamValue
+	statePath
+	stateQuery
+	stateFragment
+)
 
 // IsURL returns true if a DID has a Path, a Query or a Fragment
 // https://w3c-ccg.github.io/did-spec/#dfn-did-reference
@@ -93,6 +154,21 @@ func (d *DID) IsURL() bool {
 	return (len(d.Params) > 0 || d.Path != "" || len(d.PathSegments) > 0 || d.Query != "" || d.Fragment != "")
 }
 
+// Clone returns a copy of DID that shares no slices with d
+func (d *DID) Clone() DID {
+	c := *d
+	if c.IDStrings != nil {
+		c.IDStrings = append([]string(nil), c.IDStrings...)
+	}
+	if c.Params != nil {
+		c.Params = append([]Param(nil), c.Params...)
+	}
+	if c.PathSegments != nil {
+		c.PathSegments = append([]string(nil), c.PathSegments...)
+	}
+	return c
+}
+
 // String encodes a DID struct into a valid DID string.
 // nolint: gocyclo
 func (d *DID) String() string {
@@ -162,20 +238,35 @@ func (d *DID) String() string {
 }
 
 // ParseDID parses the input string into a DID structure.
-func ParseDID(input string) (*DID, error) {
+// By default the legacy grammar is used, see WithParseMode to select
+// DID Core 1.0 grammar. The returned error is *ParseError.
+func ParseDID(input string, opts ...ParseOption) (*DID, error) {
+	var o parseOptions
+	if len(opts) > 0 {
+		o = applyParseOptions(opts)
+	}
+
+	// most of parsed DIDs have no URL parts, parse them without running
+	// the state machine
+	if did, ok := parsePlainDID(input, o.mode); ok {
+		return did, nil
+	}
+
 	// initialize the parser state
-	p := &parser{input: input, out: &DID{}}
+	p := &parser{input: input, out: &DID{}, mode: o.mode}
 
 	// the parser state machine is implemented as a loop over parser steps
-	// steps increment p.currentIndex as they consume the input, each step returns the next step to run
-	// the state machine halts when one of the steps returns nil
+	// steps increment p.currentIndex as they consume the input, each step returns the next state
+	// the state machine halts when one of the steps returns stateDone
 	//
 	// This design is based on this talk from Rob Pike, although the talk focuses on lexical scanning,
 	// the DID grammar is simple enough for us to combine lexing and parsing into one lexerless parse
 	// http://www.youtube.com/watch?v=HxaD_trXwRE
-	parserState := p.checkLength
-	for parserState != nil {
-		parserState = parserState()
+	// States are plain values instead of method values to avoid allocating
+	// a closure on each step.
+	state := stateCheckLength
+	for state != stateDone {
+		state = p.step(state)
 	}
 
 	// If one of the steps added an err to the parser state, exit. Return nil and the error.
@@ -184,15 +275,101 @@ func ParseDID(input string) (*DID, error) {
 		return nil, err
 	}
 
-	// join IDStrings with : to make up ID
-	p.out.ID = strings.Join(p.out.IDStrings[:], ":")
+	// idstrings are consecutive in the input, ID is the substring of the
+	// input from the first idstring to the last one
+	idStart := len("did:") + len(p.out.Method) + 1
+	p.out.ID = input[idStart:p.idEnd]
 
-	// join PathSegments with / to make up Path
-	p.out.Path = strings.Join(p.out.PathSegments[:], "/")
+	// path segments are consecutive too
+	p.out.Path = input[p.pathStart:p.pathEnd]
 
 	return p.out, nil
 }
 
+// step runs the parser step handling the state
+func (p *parser) step(state parserState) parserState {
+	switch state {
+	case stateCheckLength:
+		return p.checkLength()
+	case stateScheme:
+		return p.parseScheme()
+	case stateMethod:
+		return p.parseMethod()
+	case stateID:
+		return p.parseID()
+	case stateParamName:
+		return p.parseParamName()
+	case stateParamValue:
+		return p.parseParamValue()
+	case statePath:
+		return p.parsePath()
+	case stateQuery:
+		return p.parseQuery()
+	case stateFragment:
+		return p.parseFragment()
+	default:
+		return stateDone
+	}
+}
+
+// parsePlainDID parses the input that is a DID without params, path, query
+// and fragment, with non-empty idstrings that are not pct-encoded. Returns
+// false if the input is not such DID, then it must be parsed by the parser
+// state machine that also reports errors.
+func parsePlainDID(input string, mode ParseMode) (*DID, bool) {
+	if len(input) < 7 || input[:4] != "did:" {
+		return nil, false
+	}
+
+	methodEnd := 4
+	for ; methodEnd < len(input) && input[methodEnd] != ':'; methodEnd++ {
+		if isNotDigit(input[methodEnd]) &&
+			isNotSmallLetter(input[methodEnd]) {
+
+			return nil, false
+		}
+	}
+	if methodEnd == 4 || methodEnd == len(input) {
+		return nil, false
+	}
+
+	idStart := methodEnd + 1
+	idStringsNum := 1
+	prev := byte(':')
+	for i := idStart; i < len(input); i++ {
+		char := input[i]
+		if char == ':' {
+			if prev == ':' {
+				return nil, false
+			}
+			idStringsNum++
+		} else if isNotValidIDChar(char) &&
+			(mode == ParseModeLegacy || char != '_') {
+
+			return nil, false
+		}
+		prev = char
+	}
+	if prev == ':' {
+		return nil, false
+	}
+
+	did := &DID{
+		Method:    input[4:methodEnd],
+		ID:        input[idStart:],
+		IDStrings: make([]string, 0, idStringsNum),
+	}
+	start := idStart
+	for i := idStart; i < len(input); i++ {
+		if input[i] == ':' {
+			did.IDStrings = append(did.IDStrings, input[start:i])
+			start = i + 1
+		}
+	}
+	did.IDStrings = append(did.IDStrings, input[start:])
+	return did, true
+}
+
 // checkLength is a parserStep that checks if the input length is atleast 7
 // the grammar requires
 //
@@ -204,28 +381,28 @@ func ParseDID(input string) (*DID, error) {
 // i.e. at least 7 chars
 // The current specification does not take a position on maximum length of a DID.
 // https://w3c-ccg.github.io/did-spec/#upper-limits-on-did-character-length
-func (p *parser) checkLength() parserStep {
+func (p *parser) checkLength() parserState {
 	inputLength := len(p.input)
 
 	if inputLength < 7 {
-		return p.errorf(inputLength, "input length is less than 7")
+		return p.errorf(inputLength, ComponentScheme, ErrDIDTooShort, "input length is less than 7")
 	}
 
-	return p.parseScheme
+	return stateScheme
 }
 
 // parseScheme is a parserStep that validates that the input begins with 'did:'
-func (p *parser) parseScheme() parserStep {
+func (p *parser) parseScheme() parserState {
 
 	currentIndex := 3 // 4 bytes in 'did:', i.e index 3
 
 	// the grammar requires `did:` prefix
 	if p.input[:currentIndex+1] != "did:" {
-		return p.errorf(currentIndex, "input does not begin with 'did:' prefix")
+		return p.errorf(currentIndex, ComponentScheme, ErrInvalidScheme, "input does not begin with 'did:' prefix")
 	}
 
 	p.currentIndex = currentIndex
-	return p.parseMethod
+	return stateMethod
 }
 
 // parseMethod is a parserStep that extracts the DID Method
@@ -234,7 +411,7 @@ func (p *parser) parseScheme() parserStep {
 //	did        = "did:" method ":" specific-idstring
 //	method     = 1*methodchar
 //	methodchar = %x61-7A / DIGIT ; 61-7A is a-z in US-ASCII
-func (p *parser) parseMethod() parserStep {
+func (p *parser) parseMethod() parserState {
 	input := p.input
 	inputLength := len(input)
 	currentIndex := p.currentIndex + 1
@@ -246,7 +423,7 @@ func (p *parser) parseMethod() parserStep {
 	for {
 		if currentIndex == inputLength {
 			// we got to the end of the input and didn't find a second ':'
-			return p.errorf(currentIndex, "input does not have a second `:` marking end of method name")
+			return p.errorf(currentIndex, ComponentMethod, ErrMissingMethodSeparator, "input does not have a second `:` marking end of method name")
 		}
 
 		// read the input character at currentIndex
@@ -256,14 +433,14 @@ func (p *parser) parseMethod() parserStep {
 			// we've found the second : in the input that marks the end of the method
 			if currentIndex == startIndex {
 				// return error is method is empty, ex- did::1234
-				return p.errorf(currentIndex, "method is empty")
+				return p.errorf(currentIndex, ComponentMethod, ErrEmptyComponent, "method is empty")
 			}
 			break
 		}
 
 		// as per the grammar method can only be made of digits 0-9 or small letters a-z
 		if isNotDigit(char) && isNotSmallLetter(char) {
-			return p.errorf(currentIndex, "character is not a-z OR 0-9")
+			return p.errorf(currentIndex, ComponentMethod, ErrInvalidCharacter, "character is not a-z OR 0-9")
 		}
 
 		// move to the next char
@@ -275,7 +452,7 @@ func (p *parser) parseMethod() parserStep {
 	p.out.Method = input[startIndex:currentIndex]
 
 	// method is followed by specific-idstring, parse that next
-	return p.parseID
+	return stateID
 }
 
 // parseID is a parserStep that extracts : separated idstrings that are part of a specific-idstring
@@ -287,18 +464,18 @@ func (p *parser) parseMethod() parserStep {
 //	idchar            = ALPHA / DIGIT / "." / "-"
 //
 // p.out.IDStrings is later concatented by the ParseDID function before it returns.
-func (p *parser) parseID() parserStep {
+func (p *parser) parseID() parserState {
 	input := p.input
 	inputLength := len(input)
 	currentIndex := p.currentIndex + 1
 	startIndex := currentIndex
 
-	var next parserStep
+	var next parserState
 
 	for {
 		if currentIndex == inputLength {
 			// we've reached end of input, no next state
-			next = nil
+			next = stateDone
 			break
 		}
 
@@ -306,60 +483,102 @@ func (p *parser) parseID() parserStep {
 
 		if char == ':' {
 			// encountered : input may have another idstring, parse ID again
-			next = p.parseID
+			next = stateID
 			break
 		}
 
-		if char == ';' {
+		if char == ';' && p.mode == ParseModeLegacy {
 			// encountered ; input may have a parameter, parse that next
-			next = p.parseParamName
+			next = stateParamName
 			break
 		}
 
 		if char == '/' {
 			// encountered / input may have a path following specific-idstring, parse that next
-			next = p.parsePath
+			next = statePath
 			break
 		}
 
 		if char == '?' {
 			// encountered ? input may have a query following specific-idstring, parse that next
-			next = p.parseQuery
+			next = stateQuery
 			break
 		}
 
 		if char == '#' {
 			// encountered # input may have a fragment following specific-idstring, parse that next
-			next = p.parseFragment
+			next = stateFragment
 			break
 		}
 
+		if p.mode == ParseModeCore {
+			// idchar = ALPHA / DIGIT / "." / "-" / "_" / pct-encoded
+			if char == '%' {
+				if (currentIndex+2 >= inputLength) ||
+					isNotHexDigit(input[currentIndex+1]) ||
+					isNotHexDigit(input[currentIndex+2]) {
+					return p.errorf(currentIndex, ComponentID, ErrInvalidPctEncoding, "%% is not followed by 2 hex digits")
+				}
+				currentIndex = currentIndex + 3
+				continue
+			}
+
+			if isNotValidCoreIDChar(char) {
+				return p.errorf(currentIndex, ComponentID, ErrInvalidCharacter, "byte is not ALPHA OR DIGIT OR '.' OR '-' OR '_' OR pct-encoded")
+			}
+
+			currentIndex = currentIndex + 1
+			continue
+		}
+
 		// make sure current char is a valid idchar
 		// idchar = ALPHA / DIGIT / "." / "-"
 		if isNotValidIDChar(char) {
-			return p.errorf(currentIndex, "byte is not ALPHA OR DIGIT OR '.' OR '-'")
+			return p.errorf(currentIndex, ComponentID, ErrInvalidCharacter, "byte is not ALPHA OR DIGIT OR '.' OR '-'")
 		}
 
 		// move to the next char
 		currentIndex = currentIndex + 1
 	}
 
-	if currentIndex == startIndex {
+	// from the DID Core grammar only the last idstring must be non-empty:
+	//   method-specific-id = *( *idchar ":" ) 1*idchar
+	emptyAllowed := p.mode == ParseModeCore && next != stateDone && input[currentIndex] == ':'
+	if currentIndex == startIndex && !emptyAllowed {
 		// idstring length is zero
 		// from the grammar:
 		//   idstring = 1*idchar
 		// return error because idstring is empty, ex- did:a::123:456
-		return p.errorf(currentIndex, "idstring must be atleast one char long")
+		return p.errorf(currentIndex, ComponentID, ErrEmptyComponent, "idstring must be atleast one char long")
 	}
 
 	// set parser state
 	p.currentIndex = currentIndex
+	if p.out.IDStrings == nil {
+		p.out.IDStrings = make([]string, 0, countIDStrings(input[startIndex:]))
+	}
 	p.out.IDStrings = append(p.out.IDStrings, input[startIndex:currentIndex])
+	p.idEnd = currentIndex
 
 	// return the next parser step
 	return next
 }
 
+// countIDStrings returns the number of idstrings in the method-specific-id
+// at the beginning of s. It is used to allocate IDStrings once.
+func countIDStrings(s string) int {
+	n := 1
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case ':':
+			n++
+		case ';', '/', '?', '#':
+			return n
+		}
+	}
+	return n
+}
+
 // parseParamName is a parserStep that extracts a did-url param-name.
 // A Param struct is created for each param name that is encountered.
 // from the grammar:
@@ -367,7 +586,7 @@ func (p *parser) parseID() parserStep {
 //	param              = param-name [ "=" param-value ]
 //	param-name         = 1*param-char
 //	param-char         = ALPHA / DIGIT / "." / "-" / "_" / ":" / pct-encoded
-func (p *parser) parseParamName() parserStep {
+func (p *parser) parseParamName() parserState {
 	input := p.input
 	startIndex := p.currentIndex + 1
 	next := p.paramTransition()
@@ -378,7 +597,7 @@ func (p *parser) parseParamName() parserStep {
 		// from the grammar:
 		//   1*param-char
 		// return error because param-name is empty, ex- did:a::123:456;param-name
-		return p.errorf(currentIndex, "Param name must be at least one char long")
+		return p.errorf(currentIndex, ComponentParam, ErrEmptyComponent, "Param name must be at least one char long")
 	}
 
 	// Create a new param with the name
@@ -395,7 +614,7 @@ func (p *parser) parseParamName() parserStep {
 //	param              = param-name [ "=" param-value ]
 //	param-value         = 1*param-char
 //	param-char         = ALPHA / DIGIT / "." / "-" / "_" / ":" / pct-encoded
-func (p *parser) parseParamValue() parserStep {
+func (p *parser) parseParamValue() parserState {
 	input := p.input
 	startIndex := p.currentIndex + 1
 	next := p.paramTransition()
@@ -412,19 +631,19 @@ func (p *parser) parseParamValue() parserStep {
 // paramTransition is a parserStep that extracts and transitions a param-name or
 // param-value.
 // nolint: gocyclo
-func (p *parser) paramTransition() parserStep {
+func (p *parser) paramTransition() parserState {
 	input := p.input
 	inputLength := len(input)
 	currentIndex := p.currentIndex + 1
 
 	var indexIncrement int
-	var next parserStep
+	var next parserState
 	var percentEncoded bool
 
 	for {
 		if currentIndex == inputLength {
 			// we've reached end of input, no next state
-			next = nil
+			next = stateDone
 			break
 		}
 
@@ -432,32 +651,32 @@ func (p *parser) paramTransition() parserStep {
 
 		if char == ';' {
 			// encountered : input may have another param, parse paramName again
-			next = p.parseParamName
+			next = stateParamName
 			break
 		}
 
 		// Separate steps for name and value?
 		if char == '=' {
 			// parse param value
-			next = p.parseParamValue
+			next = stateParamValue
 			break
 		}
 
 		if char == '/' {
 			// encountered / input may have a path following current param, parse that next
-			next = p.parsePath
+			next = statePath
 			break
 		}
 
 		if char == '?' {
 			// encountered ? input may have a query following current param, parse that next
-			next = p.parseQuery
+			next = stateQuery
 			break
 		}
 
 		if char == '#' {
 			// encountered # input may have a fragment following current param, parse that next
-			next = p.parseFragment
+			next = stateFragment
 			break
 		}
 
@@ -466,7 +685,7 @@ func (p *parser) paramTransition() parserStep {
 			if (currentIndex+2 >= inputLength) ||
 				isNotHexDigit(input[currentIndex+1]) ||
 				isNotHexDigit(input[currentIndex+2]) {
-				return p.errorf(currentIndex, "%% is not followed by 2 hex digits")
+				return p.errorf(currentIndex, ComponentParam, ErrInvalidPctEncoding, "%% is not followed by 2 hex digits")
 			}
 			// if we got here, we're dealing with percent encoded char, jump three chars
 			percentEncoded = true
@@ -480,7 +699,7 @@ func (p *parser) paramTransition() parserStep {
 		// make sure current char is a valid param-char
 		// idchar = ALPHA / DIGIT / "." / "-"
 		if !percentEncoded && isNotValidParamChar(char) {
-			return p.errorf(currentIndex, "character is not allowed in param - %c", char)
+			return p.errorf(currentIndex, ComponentParam, ErrInvalidCharacter, "character is not allowed in param - %c", char)
 		}
 
 		// move to the next char
@@ -505,19 +724,19 @@ func (p *parser) paramTransition() parserStep {
 //	sub-delims    = "!" / "$" / "&" / "'" / "(" / ")" / "*" / "+" / "," / ";" / "="
 //
 // nolint: gocyclo
-func (p *parser) parsePath() parserStep {
+func (p *parser) parsePath() parserState {
 	input := p.input
 	inputLength := len(input)
 	currentIndex := p.currentIndex + 1
 	startIndex := currentIndex
 
 	var indexIncrement int
-	var next parserStep
+	var next parserState
 	var percentEncoded bool
 
 	for {
 		if currentIndex == inputLength {
-			next = nil
+			next = stateDone
 			break
 		}
 
@@ -525,13 +744,19 @@ func (p *parser) parsePath() parserStep {
 
 		if char == '/' {
 			// encountered / input may have another path segment, try to parse that next
-			next = p.parsePath
+			next = statePath
 			break
 		}
 
 		if char == '?' {
 			// encountered ? input may have a query following path, parse that next
-			next = p.parseQuery
+			next = stateQuery
+			break
+		}
+
+		if char == '#' && p.mode == ParseModeCore {
+			// encountered # input may have a fragment following path, parse that next
+			next = stateFragment
 			break
 		}
 
@@ -540,7 +765,7 @@ func (p *parser) parsePath() parserStep {
 			if (currentIndex+2 >= inputLength) ||
 				isNotHexDigit(input[currentIndex+1]) ||
 				isNotHexDigit(input[currentIndex+2]) {
-				return p.errorf(currentIndex, "%% is not followed by 2 hex digits")
+				return p.errorf(currentIndex, ComponentPath, ErrInvalidPctEncoding, "%% is not followed by 2 hex digits")
 			}
 			// if we got here, we're dealing with percent encoded char, jump three chars
 			percentEncoded = true
@@ -553,24 +778,29 @@ func (p *parser) parsePath() parserStep {
 
 		// pchar = unreserved / pct-encoded / sub-delims / ":" / "@"
 		if !percentEncoded && isNotValidPathChar(char) {
-			return p.errorf(currentIndex, "character is not allowed in path")
+			return p.errorf(currentIndex, ComponentPath, ErrInvalidCharacter, "character is not allowed in path")
 		}
 
 		// move to the next char
 		currentIndex = currentIndex + indexIncrement
 	}
 
-	if currentIndex == startIndex && len(p.out.PathSegments) == 0 {
+	if currentIndex == startIndex && len(p.out.PathSegments) == 0 &&
+		p.mode == ParseModeLegacy {
 		// path segment length is zero
 		// first path segment must have atleast one character
 		// from the grammar
 		//   did-path = segment-nz *( "/" segment )
-		return p.errorf(currentIndex, "first path segment must have atleast one character")
+		return p.errorf(currentIndex, ComponentPath, ErrEmptyComponent, "first path segment must have atleast one character")
 	}
 
 	// update parser state
 	p.currentIndex = currentIndex
+	if len(p.out.PathSegments) == 0 {
+		p.pathStart = startIndex
+	}
 	p.out.PathSegments = append(p.out.PathSegments, input[startIndex:currentIndex])
+	p.pathEnd = currentIndex
 
 	return next
 }
@@ -583,14 +813,14 @@ func (p *parser) parsePath() parserStep {
 //	unreserved    = ALPHA / DIGIT / "-" / "." / "_" / "~"
 //	pct-encoded   = "%" HEXDIG HEXDIG
 //	sub-delims    = "!" / "$" / "&" / "'" / "(" / ")" / "*" / "+" / "," / ";" / "="
-func (p *parser) parseQuery() parserStep {
+func (p *parser) parseQuery() parserState {
 	input := p.input
 	inputLength := len(input)
 	currentIndex := p.currentIndex + 1
 	startIndex := currentIndex
 
 	var indexIncrement int
-	var next parserStep
+	var next parserState
 	var percentEncoded bool
 
 	for {
@@ -605,7 +835,7 @@ func (p *parser) parseQuery() parserStep {
 
 		if char == '#' {
 			// encountered # input may have a fragment following the query, parse that next
-			next = p.parseFragment
+			next = stateFragment
 			break
 		}
 
@@ -614,7 +844,7 @@ func (p *parser) parseQuery() parserStep {
 			if (currentIndex+2 >= inputLength) ||
 				isNotHexDigit(input[currentIndex+1]) ||
 				isNotHexDigit(input[currentIndex+2]) {
-				return p.errorf(currentIndex, "%% is not followed by 2 hex digits")
+				return p.errorf(currentIndex, ComponentQuery, ErrInvalidPctEncoding, "%% is not followed by 2 hex digits")
 			}
 			// if we got here, we're dealing with percent encoded char, jump three chars
 			percentEncoded = true
@@ -629,7 +859,7 @@ func (p *parser) parseQuery() parserStep {
 		// pchar = unreserved / pct-encoded / sub-delims / ":" / "@"
 		// isNotValidQueryOrFragmentChar checks for all the valid chars except pct-encoded
 		if !percentEncoded && isNotValidQueryOrFragmentChar(char) {
-			return p.errorf(currentIndex, "character is not allowed in query - %c", char)
+			return p.errorf(currentIndex, ComponentQuery, ErrInvalidCharacter, "character is not allowed in query - %c", char)
 		}
 
 		// move to the next char
@@ -651,7 +881,7 @@ func (p *parser) parseQuery() parserStep {
 //	unreserved    = ALPHA / DIGIT / "-" / "." / "_" / "~"
 //	pct-encoded   = "%" HEXDIG HEXDIG
 //	sub-delims    = "!" / "$" / "&" / "'" / "(" / ")" / "*" / "+" / "," / ";" / "="
-func (p *parser) parseFragment() parserStep {
+func (p *parser) parseFragment() parserState {
 	input := p.input
 	inputLength := len(input)
 	currentIndex := p.currentIndex + 1
@@ -675,7 +905,7 @@ func (p *parser) parseFragment() parserStep {
 			if (currentIndex+2 >= inputLength) ||
 				isNotHexDigit(input[currentIndex+1]) ||
 				isNotHexDigit(input[currentIndex+2]) {
-				return p.errorf(currentIndex, "%% is not followed by 2 hex digits")
+				return p.errorf(currentIndex, ComponentFragment, ErrInvalidPctEncoding, "%% is not followed by 2 hex digits")
 			}
 			// if we got here, we're dealing with percent encoded char, jump three chars
 			percentEncoded = true
@@ -690,7 +920,7 @@ func (p *parser) parseFragment() parserStep {
 		// pchar = unreserved / pct-encoded / sub-delims / ":" / "@"
 		// isNotValidQueryOrFragmentChar checks for all the valid chars except pct-encoded
 		if !percentEncoded && isNotValidQueryOrFragmentChar(char) {
-			return p.errorf(currentIndex, "character is not allowed in fragment - %c", char)
+			return p.errorf(currentIndex, ComponentFragment, ErrInvalidCharacter, "character is not allowed in fragment - %c", char)
 		}
 
 		// move to the next char
@@ -703,16 +933,24 @@ func (p *parser) parseFragment() parserStep {
 
 	// no more parsing needed after a fragment,
 	// cause the state machine to exit by returning nil
-	return nil
+	return stateDone
 }
 
 // errorf is a parserStep that returns nil to cause the state machine to exit
 // before returning it sets the currentIndex and err field in parser state
 // other parser steps use this function to exit the state machine with an error
-func (p *parser) errorf(index int, format string, args ...interface{}) parserStep {
+func (p *parser) errorf(index int, component ParseComponent, kind error,
+	format string, args ...interface{}) parserState {
+
 	p.currentIndex = index
-	p.err = fmt.Errorf(format, args...)
-	return nil
+	p.err = &ParseError{
+		Input:     p.input,
+		Offset:    index,
+		Component: component,
+		Kind:      kind,
+		msg:       fmt.Sprintf(format, args...),
+	}
+	return stateDone
 }
 
 // INLINABLE
@@ -727,6 +965,16 @@ func isNotValidIDChar(char byte) bool {
 	return isNotAlpha(char) && isNotDigit(char) && char != '.' && char != '-'
 }
 
+// isNotValidCoreIDChar returns true if a byte is not allowed in a ID
+// by DID Core grammar:
+//
+//	idchar = ALPHA / DIGIT / "." / "-" / "_" / pct-encoded
+//
+// pct-encoded is not checked in this function
+func isNotValidCoreIDChar(char byte) bool {
+	return isNotValidIDChar(char) && char != '_'
+}
+
 // isNotValidParamChar returns true if a byte is not allowed in a param-name
 // or param-value from the grammar:
 //
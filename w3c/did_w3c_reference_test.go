@@ -0,0 +1,712 @@
+package w3c
+
+// This file keeps the closure-based implementation of ParseDID, which was
+// replaced by the allocation-light parser. It is used as the reference in
+// the differential test.
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// the parsers internal state
+type refParser struct {
+	input        string    // input to the refParser
+	currentIndex int       // index in the input which the refParser is currently processing
+	out          *DID      // the output DID that the refParser will assemble as it steps through its state machine
+	err          error     // an error in the refParser state machine
+	mode         ParseMode // grammar the refParser follows
+}
+
+// a step in the refParser state machine that returns the next step
+type refParserStep func() refParserStep
+
+// refParseDID is ParseDID implemented with closure-based state machine
+func refParseDID(input string, opts ...ParseOption) (*DID, error) {
+	var o parseOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// initialize the refParser state
+	p := &refParser{input: input, out: &DID{}, mode: o.mode}
+
+	// the refParser state machine is implemented as a loop over refParser steps
+	// steps increment p.currentIndex as they consume the input, each step returns the next step to run
+	// the state machine halts when one of the steps returns nil
+	//
+	// This design is based on this talk from Rob Pike, although the talk focuses on lexical scanning,
+	// the DID grammar is simple enough for us to combine lexing and parsing into one lexerless parse
+	// http://www.youtube.com/watch?v=HxaD_trXwRE
+	parserState := p.checkLength
+	for parserState != nil {
+		parserState = parserState()
+	}
+
+	// If one of the steps added an err to the refParser state, exit. Return nil and the error.
+	err := p.err
+	if err != nil {
+		return nil, err
+	}
+
+	// join IDStrings with : to make up ID
+	p.out.ID = strings.Join(p.out.IDStrings[:], ":")
+
+	// join PathSegments with / to make up Path
+	p.out.Path = strings.Join(p.out.PathSegments[:], "/")
+
+	return p.out, nil
+}
+
+// checkLength is a refParserStep that checks if the input length is atleast 7
+// the grammar requires
+//
+//	`did:` prefix (4 chars)
+//	+ atleast one methodchar (1 char)
+//	+ `:` (1 char)
+//	+ atleast one idchar (1 char)
+//
+// i.e. at least 7 chars
+// The current specification does not take a position on maximum length of a DID.
+// https://w3c-ccg.github.io/did-spec/#upper-limits-on-did-character-length
+func (p *refParser) checkLength() refParserStep {
+	inputLength := len(p.input)
+
+	if inputLength < 7 {
+		return p.errorf(inputLength, ComponentScheme, ErrDIDTooShort, "input length is less than 7")
+	}
+
+	return p.parseScheme
+}
+
+// parseScheme is a refParserStep that validates that the input begins with 'did:'
+func (p *refParser) parseScheme() refParserStep {
+
+	currentIndex := 3 // 4 bytes in 'did:', i.e index 3
+
+	// the grammar requires `did:` prefix
+	if p.input[:currentIndex+1] != "did:" {
+		return p.errorf(currentIndex, ComponentScheme, ErrInvalidScheme, "input does not begin with 'did:' prefix")
+	}
+
+	p.currentIndex = currentIndex
+	return p.parseMethod
+}
+
+// parseMethod is a refParserStep that extracts the DID Method
+// from the grammar:
+//
+//	did        = "did:" method ":" specific-idstring
+//	method     = 1*methodchar
+//	methodchar = %x61-7A / DIGIT ; 61-7A is a-z in US-ASCII
+func (p *refParser) parseMethod() refParserStep {
+	input := p.input
+	inputLength := len(input)
+	currentIndex := p.currentIndex + 1
+	startIndex := currentIndex
+
+	// parse method name
+	// loop over every byte following the ':' in 'did:' unlil the second ':'
+	// method is the string between the two ':'s
+	for {
+		if currentIndex == inputLength {
+			// we got to the end of the input and didn't find a second ':'
+			return p.errorf(currentIndex, ComponentMethod, ErrMissingMethodSeparator, "input does not have a second `:` marking end of method name")
+		}
+
+		// read the input character at currentIndex
+		char := input[currentIndex]
+
+		if char == ':' {
+			// we've found the second : in the input that marks the end of the method
+			if currentIndex == startIndex {
+				// return error is method is empty, ex- did::1234
+				return p.errorf(currentIndex, ComponentMethod, ErrEmptyComponent, "method is empty")
+			}
+			break
+		}
+
+		// as per the grammar method can only be made of digits 0-9 or small letters a-z
+		if isNotDigit(char) && isNotSmallLetter(char) {
+			return p.errorf(currentIndex, ComponentMethod, ErrInvalidCharacter, "character is not a-z OR 0-9")
+		}
+
+		// move to the next char
+		currentIndex = currentIndex + 1
+	}
+
+	// set refParser state
+	p.currentIndex = currentIndex
+	p.out.Method = input[startIndex:currentIndex]
+
+	// method is followed by specific-idstring, parse that next
+	return p.parseID
+}
+
+// parseID is a refParserStep that extracts : separated idstrings that are part of a specific-idstring
+// and adds them to p.out.IDStrings
+// from the grammar:
+//
+//	specific-idstring = idstring *( ":" idstring )
+//	idstring          = 1*idchar
+//	idchar            = ALPHA / DIGIT / "." / "-"
+//
+// p.out.IDStrings is later concatented by the ParseDID function before it returns.
+func (p *refParser) parseID() refParserStep {
+	input := p.input
+	inputLength := len(input)
+	currentIndex := p.currentIndex + 1
+	startIndex := currentIndex
+
+	var next refParserStep
+
+	for {
+		if currentIndex == inputLength {
+			// we've reached end of input, no next state
+			next = nil
+			break
+		}
+
+		char := input[currentIndex]
+
+		if char == ':' {
+			// encountered : input may have another idstring, parse ID again
+			next = p.parseID
+			break
+		}
+
+		if char == ';' && p.mode == ParseModeLegacy {
+			// encountered ; input may have a parameter, parse that next
+			next = p.parseParamName
+			break
+		}
+
+		if char == '/' {
+			// encountered / input may have a path following specific-idstring, parse that next
+			next = p.parsePath
+			break
+		}
+
+		if char == '?' {
+			// encountered ? input may have a query following specific-idstring, parse that next
+			next = p.parseQuery
+			break
+		}
+
+		if char == '#' {
+			// encountered # input may have a fragment following specific-idstring, parse that next
+			next = p.parseFragment
+			break
+		}
+
+		if p.mode == ParseModeCore {
+			// idchar = ALPHA / DIGIT / "." / "-" / "_" / pct-encoded
+			if char == '%' {
+				if (currentIndex+2 >= inputLength) ||
+					isNotHexDigit(input[currentIndex+1]) ||
+					isNotHexDigit(input[currentIndex+2]) {
+					return p.errorf(currentIndex, ComponentID, ErrInvalidPctEncoding, "%% is not followed by 2 hex digits")
+				}
+				currentIndex = currentIndex + 3
+				continue
+			}
+
+			if isNotValidCoreIDChar(char) {
+				return p.errorf(currentIndex, ComponentID, ErrInvalidCharacter, "byte is not ALPHA OR DIGIT OR '.' OR '-' OR '_' OR pct-encoded")
+			}
+
+			currentIndex = currentIndex + 1
+			continue
+		}
+
+		// make sure current char is a valid idchar
+		// idchar = ALPHA / DIGIT / "." / "-"
+		if isNotValidIDChar(char) {
+			return p.errorf(currentIndex, ComponentID, ErrInvalidCharacter, "byte is not ALPHA OR DIGIT OR '.' OR '-'")
+		}
+
+		// move to the next char
+		currentIndex = currentIndex + 1
+	}
+
+	// from the DID Core grammar only the last idstring must be non-empty:
+	//   method-specific-id = *( *idchar ":" ) 1*idchar
+	emptyAllowed := p.mode == ParseModeCore && next != nil && input[currentIndex] == ':'
+	if currentIndex == startIndex && !emptyAllowed {
+		// idstring length is zero
+		// from the grammar:
+		//   idstring = 1*idchar
+		// return error because idstring is empty, ex- did:a::123:456
+		return p.errorf(currentIndex, ComponentID, ErrEmptyComponent, "idstring must be atleast one char long")
+	}
+
+	// set refParser state
+	p.currentIndex = currentIndex
+	p.out.IDStrings = append(p.out.IDStrings, input[startIndex:currentIndex])
+
+	// return the next refParser step
+	return next
+}
+
+// parseParamName is a refParserStep that extracts a did-url param-name.
+// A Param struct is created for each param name that is encountered.
+// from the grammar:
+//
+//	param              = param-name [ "=" param-value ]
+//	param-name         = 1*param-char
+//	param-char         = ALPHA / DIGIT / "." / "-" / "_" / ":" / pct-encoded
+func (p *refParser) parseParamName() refParserStep {
+	input := p.input
+	startIndex := p.currentIndex + 1
+	next := p.paramTransition()
+	currentIndex := p.currentIndex
+
+	if currentIndex == startIndex {
+		// param-name length is zero
+		// from the grammar:
+		//   1*param-char
+		// return error because param-name is empty, ex- did:a::123:456;param-name
+		return p.errorf(currentIndex, ComponentParam, ErrEmptyComponent, "Param name must be at least one char long")
+	}
+
+	// Create a new param with the name
+	p.out.Params = append(p.out.Params, Param{Name: input[startIndex:currentIndex], Value: ""})
+
+	// return the next refParser step
+	return next
+}
+
+// parseParamValue is a refParserStep that extracts a did-url param-value.
+// A parsed Param value requires that a Param was previously created when parsing a param-name.
+// from the grammar:
+//
+//	param              = param-name [ "=" param-value ]
+//	param-value         = 1*param-char
+//	param-char         = ALPHA / DIGIT / "." / "-" / "_" / ":" / pct-encoded
+func (p *refParser) parseParamValue() refParserStep {
+	input := p.input
+	startIndex := p.currentIndex + 1
+	next := p.paramTransition()
+	currentIndex := p.currentIndex
+
+	// Get the last Param in the DID and append the value
+	// values may be empty according to the grammar- *param-char
+	p.out.Params[len(p.out.Params)-1].Value = input[startIndex:currentIndex]
+
+	// return the next refParser step
+	return next
+}
+
+// paramTransition is a refParserStep that extracts and transitions a param-name or
+// param-value.
+// nolint: gocyclo
+func (p *refParser) paramTransition() refParserStep {
+	input := p.input
+	inputLength := len(input)
+	currentIndex := p.currentIndex + 1
+
+	var indexIncrement int
+	var next refParserStep
+	var percentEncoded bool
+
+	for {
+		if currentIndex == inputLength {
+			// we've reached end of input, no next state
+			next = nil
+			break
+		}
+
+		char := input[currentIndex]
+
+		if char == ';' {
+			// encountered : input may have another param, parse paramName again
+			next = p.parseParamName
+			break
+		}
+
+		// Separate steps for name and value?
+		if char == '=' {
+			// parse param value
+			next = p.parseParamValue
+			break
+		}
+
+		if char == '/' {
+			// encountered / input may have a path following current param, parse that next
+			next = p.parsePath
+			break
+		}
+
+		if char == '?' {
+			// encountered ? input may have a query following current param, parse that next
+			next = p.parseQuery
+			break
+		}
+
+		if char == '#' {
+			// encountered # input may have a fragment following current param, parse that next
+			next = p.parseFragment
+			break
+		}
+
+		if char == '%' {
+			// a % must be followed by 2 hex digits
+			if (currentIndex+2 >= inputLength) ||
+				isNotHexDigit(input[currentIndex+1]) ||
+				isNotHexDigit(input[currentIndex+2]) {
+				return p.errorf(currentIndex, ComponentParam, ErrInvalidPctEncoding, "%% is not followed by 2 hex digits")
+			}
+			// if we got here, we're dealing with percent encoded char, jump three chars
+			percentEncoded = true
+			indexIncrement = 3
+		} else {
+			// not percent encoded
+			percentEncoded = false
+			indexIncrement = 1
+		}
+
+		// make sure current char is a valid param-char
+		// idchar = ALPHA / DIGIT / "." / "-"
+		if !percentEncoded && isNotValidParamChar(char) {
+			return p.errorf(currentIndex, ComponentParam, ErrInvalidCharacter, "character is not allowed in param - %c", char)
+		}
+
+		// move to the next char
+		currentIndex = currentIndex + indexIncrement
+	}
+
+	// set refParser state
+	p.currentIndex = currentIndex
+
+	return next
+}
+
+// parsePath is a refParserStep that extracts a DID Path from a DID Reference
+// from the grammar:
+//
+//	did-path      = segment-nz *( "/" segment )
+//	segment       = *pchar
+//	segment-nz    = 1*pchar
+//	pchar         = unreserved / pct-encoded / sub-delims / ":" / "@"
+//	unreserved    = ALPHA / DIGIT / "-" / "." / "_" / "~"
+//	pct-encoded   = "%" HEXDIG HEXDIG
+//	sub-delims    = "!" / "$" / "&" / "'" / "(" / ")" / "*" / "+" / "," / ";" / "="
+//
+// nolint: gocyclo
+func (p *refParser) parsePath() refParserStep {
+	input := p.input
+	inputLength := len(input)
+	currentIndex := p.currentIndex + 1
+	startIndex := currentIndex
+
+	var indexIncrement int
+	var next refParserStep
+	var percentEncoded bool
+
+	for {
+		if currentIndex == inputLength {
+			next = nil
+			break
+		}
+
+		char := input[currentIndex]
+
+		if char == '/' {
+			// encountered / input may have another path segment, try to parse that next
+			next = p.parsePath
+			break
+		}
+
+		if char == '?' {
+			// encountered ? input may have a query following path, parse that next
+			next = p.parseQuery
+			break
+		}
+
+		if char == '#' && p.mode == ParseModeCore {
+			// encountered # input may have a fragment following path, parse that next
+			next = p.parseFragment
+			break
+		}
+
+		if char == '%' {
+			// a % must be followed by 2 hex digits
+			if (currentIndex+2 >= inputLength) ||
+				isNotHexDigit(input[currentIndex+1]) ||
+				isNotHexDigit(input[currentIndex+2]) {
+				return p.errorf(currentIndex, ComponentPath, ErrInvalidPctEncoding, "%% is not followed by 2 hex digits")
+			}
+			// if we got here, we're dealing with percent encoded char, jump three chars
+			percentEncoded = true
+			indexIncrement = 3
+		} else {
+			// not pecent encoded
+			percentEncoded = false
+			indexIncrement = 1
+		}
+
+		// pchar = unreserved / pct-encoded / sub-delims / ":" / "@"
+		if !percentEncoded && isNotValidPathChar(char) {
+			return p.errorf(currentIndex, ComponentPath, ErrInvalidCharacter, "character is not allowed in path")
+		}
+
+		// move to the next char
+		currentIndex = currentIndex + indexIncrement
+	}
+
+	if currentIndex == startIndex && len(p.out.PathSegments) == 0 &&
+		p.mode == ParseModeLegacy {
+		// path segment length is zero
+		// first path segment must have atleast one character
+		// from the grammar
+		//   did-path = segment-nz *( "/" segment )
+		return p.errorf(currentIndex, ComponentPath, ErrEmptyComponent, "first path segment must have atleast one character")
+	}
+
+	// update refParser state
+	p.currentIndex = currentIndex
+	p.out.PathSegments = append(p.out.PathSegments, input[startIndex:currentIndex])
+
+	return next
+}
+
+// parseQuery is a refParserStep that extracts a DID Query from a DID Reference
+// from the grammar:
+//
+//	did-query     = *( pchar / "/" / "?" )
+//	pchar         = unreserved / pct-encoded / sub-delims / ":" / "@"
+//	unreserved    = ALPHA / DIGIT / "-" / "." / "_" / "~"
+//	pct-encoded   = "%" HEXDIG HEXDIG
+//	sub-delims    = "!" / "$" / "&" / "'" / "(" / ")" / "*" / "+" / "," / ";" / "="
+func (p *refParser) parseQuery() refParserStep {
+	input := p.input
+	inputLength := len(input)
+	currentIndex := p.currentIndex + 1
+	startIndex := currentIndex
+
+	var indexIncrement int
+	var next refParserStep
+	var percentEncoded bool
+
+	for {
+		if currentIndex == inputLength {
+			// we've reached the end of input
+			// it's ok for query to be empty, so we don't need a check for that
+			// did-query     = *( pchar / "/" / "?" )
+			break
+		}
+
+		char := input[currentIndex]
+
+		if char == '#' {
+			// encountered # input may have a fragment following the query, parse that next
+			next = p.parseFragment
+			break
+		}
+
+		if char == '%' {
+			// a % must be followed by 2 hex digits
+			if (currentIndex+2 >= inputLength) ||
+				isNotHexDigit(input[currentIndex+1]) ||
+				isNotHexDigit(input[currentIndex+2]) {
+				return p.errorf(currentIndex, ComponentQuery, ErrInvalidPctEncoding, "%% is not followed by 2 hex digits")
+			}
+			// if we got here, we're dealing with percent encoded char, jump three chars
+			percentEncoded = true
+			indexIncrement = 3
+		} else {
+			// not pecent encoded
+			percentEncoded = false
+			indexIncrement = 1
+		}
+
+		// did-query = *( pchar / "/" / "?" )
+		// pchar = unreserved / pct-encoded / sub-delims / ":" / "@"
+		// isNotValidQueryOrFragmentChar checks for all the valid chars except pct-encoded
+		if !percentEncoded && isNotValidQueryOrFragmentChar(char) {
+			return p.errorf(currentIndex, ComponentQuery, ErrInvalidCharacter, "character is not allowed in query - %c", char)
+		}
+
+		// move to the next char
+		currentIndex = currentIndex + indexIncrement
+	}
+
+	// update refParser state
+	p.currentIndex = currentIndex
+	p.out.Query = input[startIndex:currentIndex]
+
+	return next
+}
+
+// parseFragment is a refParserStep that extracts a DID Fragment from a DID Reference
+// from the grammar:
+//
+//	did-fragment  = *( pchar / "/" / "?" )
+//	pchar         = unreserved / pct-encoded / sub-delims / ":" / "@"
+//	unreserved    = ALPHA / DIGIT / "-" / "." / "_" / "~"
+//	pct-encoded   = "%" HEXDIG HEXDIG
+//	sub-delims    = "!" / "$" / "&" / "'" / "(" / ")" / "*" / "+" / "," / ";" / "="
+func (p *refParser) parseFragment() refParserStep {
+	input := p.input
+	inputLength := len(input)
+	currentIndex := p.currentIndex + 1
+	startIndex := currentIndex
+
+	var indexIncrement int
+	var percentEncoded bool
+
+	for {
+		if currentIndex == inputLength {
+			// we've reached the end of input
+			// it's ok for reference to be empty, so we don't need a check for that
+			// did-fragment = *( pchar / "/" / "?" )
+			break
+		}
+
+		char := input[currentIndex]
+
+		if char == '%' {
+			// a % must be followed by 2 hex digits
+			if (currentIndex+2 >= inputLength) ||
+				isNotHexDigit(input[currentIndex+1]) ||
+				isNotHexDigit(input[currentIndex+2]) {
+				return p.errorf(currentIndex, ComponentFragment, ErrInvalidPctEncoding, "%% is not followed by 2 hex digits")
+			}
+			// if we got here, we're dealing with percent encoded char, jump three chars
+			percentEncoded = true
+			indexIncrement = 3
+		} else {
+			// not pecent encoded
+			percentEncoded = false
+			indexIncrement = 1
+		}
+
+		// did-fragment = *( pchar / "/" / "?" )
+		// pchar = unreserved / pct-encoded / sub-delims / ":" / "@"
+		// isNotValidQueryOrFragmentChar checks for all the valid chars except pct-encoded
+		if !percentEncoded && isNotValidQueryOrFragmentChar(char) {
+			return p.errorf(currentIndex, ComponentFragment, ErrInvalidCharacter, "character is not allowed in fragment - %c", char)
+		}
+
+		// move to the next char
+		currentIndex = currentIndex + indexIncrement
+	}
+
+	// update refParser state
+	p.currentIndex = currentIndex
+	p.out.Fragment = input[startIndex:currentIndex]
+
+	// no more parsing needed after a fragment,
+	// cause the state machine to exit by returning nil
+	return nil
+}
+
+// errorf is a refParserStep that returns nil to cause the state machine to exit
+// before returning it sets the currentIndex and err field in refParser state
+// other refParser steps use this function to exit the state machine with an error
+func (p *refParser) errorf(index int, component ParseComponent, kind error,
+	format string, args ...interface{}) refParserStep {
+
+	p.currentIndex = index
+	p.err = &ParseError{
+		Input:     p.input,
+		Offset:    index,
+		Component: component,
+		Kind:      kind,
+		msg:       fmt.Sprintf(format, args...),
+	}
+	return nil
+}
+
+var differentialInputs = []string{
+	"did:example:123",
+	"did:example:net:123",
+	"did:iden3:polygon:mumbai:x3HstHLj2rTp6HHXk2WczYP7w3rpCsRbwCMeaQ2H2",
+	"did:web:example.com%3A8080:user_1",
+	"did:example::123",
+	"did:example:123:",
+	"did:example:123;service=agent;foo:bar=high",
+	"did:example:123;=x",
+	"did:example:123/a/b/c",
+	"did:example:123//a",
+	"did:example:123/a//b/",
+	"did:example:123/a#frag",
+	"did:example:123?a=1&b=%2F#key-1",
+	"did:example:123#key%201",
+	"did:example:123#a#b",
+	"did:ex_ample:123",
+	"did:Example:123",
+	"did::123",
+	"xid:example:123",
+	"did:ex",
+	"did:exa",
+	"did:example:",
+}
+
+func TestParseDID_Differential(t *testing.T) {
+	tokens := []string{"did:", "did:example:", "example", ":", "a", "Z", "1",
+		".", "-", "_", "~", "%", "%2f", "%zz", ";", "=", "/", "?", "#", "@",
+		" ", "+"}
+
+	inputs := append([]string(nil), differentialInputs...)
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		input := "did:example:"
+		if rnd.Intn(10) == 0 {
+			input = ""
+		}
+		for n := rnd.Intn(8); n >= 0; n-- {
+			input += tokens[rnd.Intn(len(tokens))]
+		}
+		inputs = append(inputs, input)
+	}
+
+	for _, input := range inputs {
+		for _, mode := range []ParseMode{ParseModeLegacy, ParseModeCore} {
+			want, wantErr := refParseDID(input, WithParseMode(mode))
+			got, gotErr := ParseDID(input, WithParseMode(mode))
+			require.Equal(t, wantErr, gotErr, "input %q mode %v", input,
+				mode)
+			require.Equal(t, want, got, "input %q mode %v", input, mode)
+		}
+	}
+}
+
+var benchmarkDIDs = []struct {
+	name  string
+	input string
+}{
+	{"plain",
+		"did:iden3:polygon:mumbai:x3HstHLj2rTp6HHXk2WczYP7w3rpCsRbwCMeaQ2H2"},
+	{"url",
+		"did:iden3:polygon:mumbai:x3HstHLj2rTp6HHXk2WczYP7w3rpCsRbwCMeaQ2H2" +
+			"/a/b?state=1e5d#state-info"},
+}
+
+var benchmarkDIDResult *DID
+
+func BenchmarkParseDID(b *testing.B) {
+	for _, bm := range benchmarkDIDs {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				benchmarkDIDResult, _ = ParseDID(bm.input)
+			}
+		})
+	}
+}
+
+func BenchmarkParseDID_Reference(b *testing.B) {
+	for _, bm := range benchmarkDIDs {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				benchmarkDIDResult, _ = refParseDID(bm.input)
+			}
+		})
+	}
+}
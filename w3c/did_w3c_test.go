@@ -566,9 +566,82 @@ func TestParse(t *testing.T) {
 	})
 }
 
+func TestParse_CoreMode(t *testing.T) {
+	core := WithParseMode(ParseModeCore)
+
+	t.Run("succeeds with '_' and pct-encoded chars in idstring", func(t *testing.T) {
+		d, err := ParseDID("did:web:example.com%3A3000:user_1", core)
+		assert(t, nil, err)
+		assert(t, "example.com%3A3000:user_1", d.ID)
+		assert(t, []string{"example.com%3A3000", "user_1"}, d.IDStrings)
+	})
+
+	t.Run("fails with '_' and pct-encoded chars in legacy mode", func(t *testing.T) {
+		_, err := ParseDID("did:web:example.com%3A3000")
+		assert(t, false, err == nil)
+		_, err = ParseDID("did:a:user_1")
+		assert(t, false, err == nil)
+		_, err = ParseDID("did:a:user_1", WithParseMode(ParseModeLegacy))
+		assert(t, false, err == nil)
+	})
+
+	t.Run("returns error if % in idstring is not followed by 2 hex chars", func(t *testing.T) {
+		dids := []string{
+			"did:a:123%",
+			"did:a:123%4",
+			"did:a:123%4g:456",
+		}
+		for _, did := range dids {
+			_, err := ParseDID(did, core)
+			assert(t, false, err == nil, "Input: %s", did)
+		}
+	})
+
+	t.Run("allows empty idstrings except the last one", func(t *testing.T) {
+		d, err := ParseDID("did:a::123::456", core)
+		assert(t, nil, err)
+		assert(t, ":123::456", d.ID)
+		assert(t, []string{"", "123", "", "456"}, d.IDStrings)
+		assert(t, "did:a::123::456", d.String())
+
+		_, err = ParseDID("did:a:123:", core)
+		assert(t, false, err == nil)
+		_, err = ParseDID("did:a:123::", core)
+		assert(t, false, err == nil)
+	})
+
+	t.Run("returns error on params", func(t *testing.T) {
+		_, err := ParseDID("did:a:123:456;service=agent", core)
+		assert(t, false, err == nil)
+	})
+
+	t.Run("allows empty path segments", func(t *testing.T) {
+		d, err := ParseDID("did:a:123//abc/", core)
+		assert(t, nil, err)
+		assert(t, []string{"", "abc", ""}, d.PathSegments)
+		assert(t, "/abc/", d.Path)
+		assert(t, "did:a:123//abc/", d.String())
+	})
+
+	t.Run("succeeds to extract fragment after path", func(t *testing.T) {
+		d, err := ParseDID("did:a:123/a/b#key-1", core)
+		assert(t, nil, err)
+		assert(t, "a/b", d.Path)
+		assert(t, "key-1", d.Fragment)
+	})
+
+	t.Run("succeeds to extract query parameters", func(t *testing.T) {
+		d, err := ParseDID("did:a:123?service=files&relativeRef=%2Fresume.pdf#x", core)
+		assert(t, nil, err)
+		assert(t, "service=files&relativeRef=%2Fresume.pdf", d.Query)
+		assert(t, "x", d.Fragment)
+		assert(t, 0, len(d.Params))
+	})
+}
+
 func Test_errorf(t *testing.T) {
-	p := &parser{}
-	p.errorf(10, "%s,%s", "a", "b")
+	p := &parser{input: "did:a:b"}
+	p.errorf(10, ComponentID, ErrInvalidCharacter, "%s,%s", "a", "b")
 
 	if p.currentIndex != 10 {
 		t.Errorf("did not set currentIndex")
@@ -578,6 +651,11 @@ func Test_errorf(t *testing.T) {
 	if e != "a,b" {
 		t.Errorf("err message is: '%s' expected: 'a,b'", e)
 	}
+
+	pe, ok := p.err.(*ParseError)
+	assert(t, true, ok)
+	assert(t, ParseError{Input: "did:a:b", Offset: 10, Component: ComponentID,
+		Kind: ErrInvalidCharacter, msg: "a,b"}, *pe)
 }
 
 func Test_isNotValidParamChar(t *testing.T) {
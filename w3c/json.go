@@ -1,6 +1,11 @@
 package w3c
 
-import "encoding/json"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
 
 func (did *DID) UnmarshalJSON(bytes []byte) error {
 	var didStr string
@@ -20,3 +25,46 @@ func (did *DID) UnmarshalJSON(bytes []byte) error {
 func (did DID) MarshalJSON() ([]byte, error) {
 	return json.Marshal(did.String())
 }
+
+// MarshalText implements encoding.TextMarshaler interface, so DID is encoded
+// as text by the encoders that support it (e.g. *DID map keys in JSON).
+// Returns error if DID can't be encoded to string (e.g. Method or ID is
+// empty).
+func (did DID) MarshalText() ([]byte, error) {
+	s := did.String()
+	if s == "" {
+		return nil, errors.New("can't encode invalid DID to text")
+	}
+	return []byte(s), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler interface
+func (did *DID) UnmarshalText(text []byte) error {
+	did3, err := ParseDID(string(text))
+	if err != nil {
+		return err
+	}
+	*did = *did3
+	return nil
+}
+
+// Value implements driver.Valuer interface. DID is stored as string.
+func (did DID) Value() (driver.Value, error) {
+	text, err := did.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return string(text), nil
+}
+
+// Scan implements sql.Scanner interface. DID is scanned from string.
+func (did *DID) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case string:
+		return did.UnmarshalText([]byte(v))
+	case []byte:
+		return did.UnmarshalText(v)
+	default:
+		return fmt.Errorf("can't scan DID from %T", src)
+	}
+}
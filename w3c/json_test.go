@@ -0,0 +1,67 @@
+package w3c
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDID_MarshalText(t *testing.T) {
+	did := mustParseDID(t, "did:example:123#key-1")
+
+	text, err := did.MarshalText()
+	require.NoError(t, err)
+	require.Equal(t, "did:example:123#key-1", string(text))
+
+	var did2 DID
+	require.NoError(t, did2.UnmarshalText(text))
+	require.Equal(t, did, did2)
+
+	require.Error(t, did2.UnmarshalText([]byte("did:example")))
+
+	_, err = DID{Method: "example"}.MarshalText()
+	require.EqualError(t, err, "can't encode invalid DID to text")
+}
+
+func TestDID_JSONMapKey(t *testing.T) {
+	// DID is not comparable, but pointers to DID may be used as map keys
+	did1 := mustParseDID(t, "did:example:1")
+	did2 := mustParseDID(t, "did:example:2")
+	m := map[*DID]int{&did1: 1, &did2: 2}
+
+	b, err := json.Marshal(m)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"did:example:1":1,"did:example:2":2}`, string(b))
+
+	// keys are decoded as strings and then parsed with UnmarshalText
+	var m2 map[string]int
+	require.NoError(t, json.Unmarshal(b, &m2))
+	for k := range m2 {
+		var did DID
+		require.NoError(t, did.UnmarshalText([]byte(k)))
+	}
+}
+
+func TestDID_SQL(t *testing.T) {
+	did := mustParseDID(t, "did:example:net:123")
+
+	v, err := did.Value()
+	require.NoError(t, err)
+	require.Equal(t, "did:example:net:123", v)
+
+	var did2 DID
+	require.NoError(t, did2.Scan(v))
+	require.Equal(t, did, did2)
+
+	var did3 DID
+	require.NoError(t, did3.Scan([]byte("did:example:net:123")))
+	require.Equal(t, did, did3)
+
+	require.EqualError(t, did3.Scan(nil), "can't scan DID from <nil>")
+	require.Error(t, did3.Scan("example:123"))
+	require.Equal(t, did, did3)
+
+	_, err = DID{}.Value()
+	require.Error(t, err)
+}
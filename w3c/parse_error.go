@@ -0,0 +1,70 @@
+package w3c
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of ParseError. Use errors.Is to check the kind of error returned by
+// ParseDID.
+var (
+	// ErrDIDTooShort means the input is shorter than the shortest DID.
+	ErrDIDTooShort = errors.New("DID is too short")
+	// ErrInvalidScheme means the input does not begin with `did:`.
+	ErrInvalidScheme = errors.New("invalid DID scheme")
+	// ErrMissingMethodSeparator means there is no `:` after the method.
+	ErrMissingMethodSeparator = errors.New("missing DID method separator")
+	// ErrEmptyComponent means a component that must be non-empty is empty:
+	// method, idstring, param name or first path segment.
+	ErrEmptyComponent = errors.New("empty DID component")
+	// ErrInvalidCharacter means a character is not allowed in the component.
+	ErrInvalidCharacter = errors.New("invalid character in DID")
+	// ErrInvalidPctEncoding means `%` is not followed by 2 hex digits.
+	ErrInvalidPctEncoding = errors.New("invalid percent-encoding in DID")
+)
+
+// ParseComponent is a component of DID URL where a parse error occurred
+type ParseComponent string
+
+// DID URL components
+const (
+	// ComponentScheme is the `did:` prefix. Errors about the input as a whole
+	// (e.g. its length) are reported for this component too.
+	ComponentScheme   ParseComponent = "scheme"
+	ComponentMethod   ParseComponent = "method"
+	ComponentID       ParseComponent = "id"
+	ComponentParam    ParseComponent = "param"
+	ComponentPath     ParseComponent = "path"
+	ComponentQuery    ParseComponent = "query"
+	ComponentFragment ParseComponent = "fragment"
+)
+
+// ParseError is an error returned by ParseDID
+type ParseError struct {
+	// Input is the parsed string
+	Input string
+	// Offset is the byte offset in Input where the error occurred
+	Offset int
+	// Component is the DID URL component where the error occurred
+	Component ParseComponent
+	// Kind is one of the sentinel errors: ErrDIDTooShort, ErrInvalidScheme,
+	// ErrMissingMethodSeparator, ErrEmptyComponent, ErrInvalidCharacter,
+	// ErrInvalidPctEncoding.
+	Kind error
+
+	msg string
+}
+
+// Error returns the description of the error
+func (e *ParseError) Error() string {
+	if e.msg != "" {
+		return e.msg
+	}
+	return fmt.Sprintf("%v: %v at offset %d", e.Kind, e.Component, e.Offset)
+}
+
+// Unwrap returns Kind, so errors.Is(err, ErrInvalidCharacter) can be used to
+// check the kind of error.
+func (e *ParseError) Unwrap() error {
+	return e.Kind
+}
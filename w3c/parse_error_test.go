@@ -0,0 +1,73 @@
+package w3c
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDID_ParseError(t *testing.T) {
+	testCases := []struct {
+		input     string
+		mode      ParseMode
+		offset    int
+		component ParseComponent
+		kind      error
+	}{
+		{"did:a", ParseModeLegacy, 5, ComponentScheme, ErrDIDTooShort},
+		{"xid:a:123", ParseModeLegacy, 3, ComponentScheme, ErrInvalidScheme},
+		{"did:abcdef", ParseModeLegacy, 10, ComponentMethod,
+			ErrMissingMethodSeparator},
+		{"did::123456", ParseModeLegacy, 4, ComponentMethod,
+			ErrEmptyComponent},
+		{"did:aB:123", ParseModeLegacy, 5, ComponentMethod,
+			ErrInvalidCharacter},
+		{"did:a:1_3", ParseModeLegacy, 7, ComponentID, ErrInvalidCharacter},
+		{"did:a:1%zz", ParseModeCore, 7, ComponentID, ErrInvalidPctEncoding},
+		{"did:a:123:", ParseModeLegacy, 10, ComponentID, ErrEmptyComponent},
+		{"did:a:123;=b", ParseModeLegacy, 10, ComponentParam,
+			ErrEmptyComponent},
+		{"did:a:123;a=b^", ParseModeLegacy, 13, ComponentParam,
+			ErrInvalidCharacter},
+		{"did:a:123;a=%z", ParseModeLegacy, 12, ComponentParam,
+			ErrInvalidPctEncoding},
+		{"did:a:123/a b", ParseModeLegacy, 11, ComponentPath,
+			ErrInvalidCharacter},
+		{"did:a:123/a%", ParseModeLegacy, 11, ComponentPath,
+			ErrInvalidPctEncoding},
+		{"did:a:123//a", ParseModeLegacy, 10, ComponentPath,
+			ErrEmptyComponent},
+		{"did:a:123?a^", ParseModeLegacy, 11, ComponentQuery,
+			ErrInvalidCharacter},
+		{"did:a:123?%1", ParseModeLegacy, 10, ComponentQuery,
+			ErrInvalidPctEncoding},
+		{"did:a:123#a#", ParseModeLegacy, 11, ComponentFragment,
+			ErrInvalidCharacter},
+		{"did:a:123#%G0", ParseModeLegacy, 10, ComponentFragment,
+			ErrInvalidPctEncoding},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			_, err := ParseDID(tc.input, WithParseMode(tc.mode))
+			require.Error(t, err)
+
+			var pe *ParseError
+			require.True(t, errors.As(err, &pe))
+			require.Equal(t, tc.input, pe.Input)
+			require.Equal(t, tc.offset, pe.Offset)
+			require.Equal(t, tc.component, pe.Component)
+			require.True(t, errors.Is(err, tc.kind), err)
+		})
+	}
+}
+
+func TestParseError_Error(t *testing.T) {
+	_, err := ParseDID("did:aB:123")
+	require.EqualError(t, err, "character is not a-z OR 0-9")
+
+	err = &ParseError{Offset: 3, Component: ComponentScheme,
+		Kind: ErrInvalidScheme}
+	require.EqualError(t, err, "invalid DID scheme: scheme at offset 3")
+}
@@ -0,0 +1,277 @@
+package w3c
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DID resolution errors as defined by DID Core spec
+// https://www.w3.org/TR/did-core/#did-resolution-metadata
+// Error messages are equal to the error codes of the spec.
+var (
+	// ErrNotFound returns when the DID document was not found.
+	ErrNotFound = errors.New("notFound")
+	// ErrInvalidDID returns when the DID is not valid.
+	ErrInvalidDID = errors.New("invalidDid")
+	// ErrMethodNotSupported returns when the DID method is not supported by
+	// the resolver.
+	ErrMethodNotSupported = errors.New("methodNotSupported")
+)
+
+// ResolutionErrorInternal is an error code for all errors that are not one
+// of the DID resolution errors.
+const ResolutionErrorInternal = "internalError"
+
+// ResolutionErrorCode returns DID Core error code for the error returned
+// by Resolver. Returns empty string if err is nil.
+func ResolutionErrorCode(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrNotFound):
+		return ErrNotFound.Error()
+	case errors.Is(err, ErrInvalidDID):
+		return ErrInvalidDID.Error()
+	case errors.Is(err, ErrMethodNotSupported):
+		return ErrMethodNotSupported.Error()
+	default:
+		return ResolutionErrorInternal
+	}
+}
+
+// ResolutionOptions is a set of DID resolution input options
+// https://www.w3.org/TR/did-core/#did-resolution-options
+type ResolutionOptions struct {
+	// Accept is a media type of the preferred representation of DID
+	// document.
+	Accept string
+}
+
+// ResolutionMetadata is a DID resolution metadata
+// https://www.w3.org/TR/did-core/#did-resolution-metadata
+type ResolutionMetadata struct {
+	ContentType string `json:"contentType,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// DocumentMetadata is a DID document metadata
+// https://www.w3.org/TR/did-core/#did-document-metadata
+type DocumentMetadata struct {
+	Created       *time.Time `json:"created,omitempty"`
+	Updated       *time.Time `json:"updated,omitempty"`
+	Deactivated   bool       `json:"deactivated,omitempty"`
+	NextUpdate    *time.Time `json:"nextUpdate,omitempty"`
+	VersionID     string     `json:"versionId,omitempty"`
+	NextVersionID string     `json:"nextVersionId,omitempty"`
+	EquivalentID  []string   `json:"equivalentId,omitempty"`
+	CanonicalID   string     `json:"canonicalId,omitempty"`
+}
+
+// Resolver resolves a DID to the DID document
+// https://www.w3.org/TR/did-core/#did-resolution
+//
+// On failure Resolver returns an error that wraps one of ErrNotFound,
+// ErrInvalidDID or ErrMethodNotSupported, or any other error for internal
+// failures. The resolution metadata is returned in both cases.
+type Resolver interface {
+	Resolve(ctx context.Context, did DID, opts ResolutionOptions) (
+		*DIDDocument, ResolutionMetadata, DocumentMetadata, error)
+}
+
+// ResolverFunc is an adapter to allow the use of ordinary functions as
+// Resolver.
+type ResolverFunc func(ctx context.Context, did DID,
+	opts ResolutionOptions) (*DIDDocument, ResolutionMetadata,
+	DocumentMetadata, error)
+
+// Resolve calls f(ctx, did, opts).
+func (f ResolverFunc) Resolve(ctx context.Context, did DID,
+	opts ResolutionOptions) (*DIDDocument, ResolutionMetadata,
+	DocumentMetadata, error) {
+
+	return f(ctx, did, opts)
+}
+
+// MultiResolver is a Resolver that dispatches resolution to the resolver
+// registered for the DID method. It is safe for concurrent use.
+type MultiResolver struct {
+	mu        sync.RWMutex
+	resolvers map[string]Resolver
+}
+
+// NewMultiResolver creates new MultiResolver without registered methods.
+func NewMultiResolver() *MultiResolver {
+	return &MultiResolver{resolvers: make(map[string]Resolver)}
+}
+
+// Register sets resolver for DID method. Previously registered resolver for
+// the method is replaced.
+func (m *MultiResolver) Register(method string, r Resolver) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resolvers[method] = r
+}
+
+// Resolve implements Resolver interface
+func (m *MultiResolver) Resolve(ctx context.Context, did DID,
+	opts ResolutionOptions) (*DIDDocument, ResolutionMetadata,
+	DocumentMetadata, error) {
+
+	if did.Method == "" || did.IsURL() {
+		return failedResolution(fmt.Errorf("%w: %q is not a DID",
+			ErrInvalidDID, did.String()))
+	}
+
+	m.mu.RLock()
+	r, ok := m.resolvers[did.Method]
+	m.mu.RUnlock()
+	if !ok {
+		return failedResolution(fmt.Errorf("%w: %v", ErrMethodNotSupported,
+			did.Method))
+	}
+
+	return r.Resolve(ctx, did, opts)
+}
+
+func failedResolution(err error) (*DIDDocument, ResolutionMetadata,
+	DocumentMetadata, error) {
+
+	return nil, ResolutionMetadata{Error: ResolutionErrorCode(err)},
+		DocumentMetadata{}, err
+}
+
+// CachingResolver is a Resolver decorator that caches successful
+// resolution results. Cache keeps at most size items, the least recently
+// used item is evicted first. Items expire after ttl. It is safe for
+// concurrent use.
+//
+// Cached documents are shared between callers and must not be modified.
+type CachingResolver struct {
+	resolver Resolver
+	size     int
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[cacheKey]*list.Element
+}
+
+type cacheKey struct {
+	did    string
+	accept string
+}
+
+type cacheItem struct {
+	key        cacheKey
+	expiration time.Time
+	doc        *DIDDocument
+	resMeta    ResolutionMetadata
+	docMeta    DocumentMetadata
+}
+
+// NewCachingResolver creates new CachingResolver over r. If size is not
+// positive cache size is unlimited. If ttl is not positive items never
+// expire.
+func NewCachingResolver(r Resolver, size int,
+	ttl time.Duration) *CachingResolver {
+
+	return &CachingResolver{
+		resolver: r,
+		size:     size,
+		ttl:      ttl,
+		now:      time.Now,
+		ll:       list.New(),
+		items:    make(map[cacheKey]*list.Element),
+	}
+}
+
+// Resolve implements Resolver interface
+func (c *CachingResolver) Resolve(ctx context.Context, did DID,
+	opts ResolutionOptions) (*DIDDocument, ResolutionMetadata,
+	DocumentMetadata, error) {
+
+	key := cacheKey{did: did.String(), accept: opts.Accept}
+
+	if item, ok := c.get(key); ok {
+		return item.doc, item.resMeta, item.docMeta, nil
+	}
+
+	doc, resMeta, docMeta, err := c.resolver.Resolve(ctx, did, opts)
+	if err != nil {
+		return doc, resMeta, docMeta, err
+	}
+
+	c.add(&cacheItem{key: key, doc: doc, resMeta: resMeta,
+		docMeta: docMeta})
+	return doc, resMeta, docMeta, nil
+}
+
+// Invalidate removes all cached results for did.
+func (c *CachingResolver) Invalidate(did DID) {
+	didStr := did.String()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, e := range c.items {
+		if key.did == didStr {
+			c.removeElement(e)
+		}
+	}
+}
+
+// Len returns the number of cached items including expired ones that were
+// not evicted yet.
+func (c *CachingResolver) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *CachingResolver) get(key cacheKey) (*cacheItem, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	item := e.Value.(*cacheItem)
+	if c.ttl > 0 && !c.now().Before(item.expiration) {
+		c.removeElement(e)
+		return nil, false
+	}
+
+	c.ll.MoveToFront(e)
+	return item, true
+}
+
+func (c *CachingResolver) add(item *cacheItem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ttl > 0 {
+		item.expiration = c.now().Add(c.ttl)
+	}
+
+	if e, ok := c.items[item.key]; ok {
+		e.Value = item
+		c.ll.MoveToFront(e)
+		return
+	}
+
+	c.items[item.key] = c.ll.PushFront(item)
+	if c.size > 0 && c.ll.Len() > c.size {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+func (c *CachingResolver) removeElement(e *list.Element) {
+	c.ll.Remove(e)
+	delete(c.items, e.Value.(*cacheItem).key)
+}
@@ -0,0 +1,166 @@
+package w3c
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memResolver is an in-memory resolver that counts resolution calls
+type memResolver struct {
+	docs  map[string]*DIDDocument
+	calls int
+}
+
+func newMemResolver(dids ...string) *memResolver {
+	r := &memResolver{docs: make(map[string]*DIDDocument)}
+	for _, didStr := range dids {
+		did, err := ParseDID(didStr)
+		if err != nil {
+			panic(err)
+		}
+		r.docs[didStr] = &DIDDocument{
+			Context: []interface{}{DIDContextV1},
+			ID:      *did,
+		}
+	}
+	return r
+}
+
+func (r *memResolver) Resolve(_ context.Context, did DID,
+	_ ResolutionOptions) (*DIDDocument, ResolutionMetadata,
+	DocumentMetadata, error) {
+
+	r.calls++
+	doc, ok := r.docs[did.String()]
+	if !ok {
+		return failedResolution(ErrNotFound)
+	}
+	return doc, ResolutionMetadata{ContentType: "application/did+json"},
+		DocumentMetadata{}, nil
+}
+
+func TestMultiResolver(t *testing.T) {
+	ctx := context.Background()
+	exampleResolver := newMemResolver("did:example:123")
+	otherResolver := newMemResolver("did:other:456")
+
+	mr := NewMultiResolver()
+	mr.Register("example", exampleResolver)
+	mr.Register("other", otherResolver)
+
+	doc, resMeta, _, err := mr.Resolve(ctx,
+		mustParseDID(t, "did:example:123"), ResolutionOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "did:example:123", doc.ID.String())
+	require.Equal(t, "application/did+json", resMeta.ContentType)
+	require.Equal(t, 1, exampleResolver.calls)
+	require.Equal(t, 0, otherResolver.calls)
+
+	doc, _, _, err = mr.Resolve(ctx, mustParseDID(t, "did:other:456"),
+		ResolutionOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "did:other:456", doc.ID.String())
+	require.Equal(t, 1, otherResolver.calls)
+
+	_, resMeta, _, err = mr.Resolve(ctx, mustParseDID(t, "did:example:456"),
+		ResolutionOptions{})
+	require.ErrorIs(t, err, ErrNotFound)
+	require.Equal(t, "notFound", resMeta.Error)
+
+	_, resMeta, _, err = mr.Resolve(ctx, mustParseDID(t, "did:unknown:456"),
+		ResolutionOptions{})
+	require.ErrorIs(t, err, ErrMethodNotSupported)
+	require.Equal(t, "methodNotSupported", resMeta.Error)
+
+	_, resMeta, _, err = mr.Resolve(ctx,
+		mustParseDID(t, "did:example:123#key-1"), ResolutionOptions{})
+	require.ErrorIs(t, err, ErrInvalidDID)
+	require.Equal(t, "invalidDid", resMeta.Error)
+
+	_, resMeta, _, err = mr.Resolve(ctx, DID{}, ResolutionOptions{})
+	require.ErrorIs(t, err, ErrInvalidDID)
+	require.Equal(t, "invalidDid", resMeta.Error)
+}
+
+func TestResolutionErrorCode(t *testing.T) {
+	require.Equal(t, "", ResolutionErrorCode(nil))
+	require.Equal(t, "notFound",
+		ResolutionErrorCode(fmt.Errorf("%w: some DID", ErrNotFound)))
+	require.Equal(t, "invalidDid", ResolutionErrorCode(ErrInvalidDID))
+	require.Equal(t, "methodNotSupported",
+		ResolutionErrorCode(ErrMethodNotSupported))
+	require.Equal(t, "internalError",
+		ResolutionErrorCode(errors.New("connection refused")))
+}
+
+func TestCachingResolver(t *testing.T) {
+	ctx := context.Background()
+	r := newMemResolver("did:example:1", "did:example:2", "did:example:3")
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewCachingResolver(r, 2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	resolve := func(didStr string) error {
+		_, _, _, err := c.Resolve(ctx, mustParseDID(t, didStr),
+			ResolutionOptions{})
+		return err
+	}
+
+	require.NoError(t, resolve("did:example:1"))
+	require.NoError(t, resolve("did:example:1"))
+	require.Equal(t, 1, r.calls)
+
+	// different options are cached separately
+	_, _, _, err := c.Resolve(ctx, mustParseDID(t, "did:example:1"),
+		ResolutionOptions{Accept: "application/did+ld+json"})
+	require.NoError(t, err)
+	require.Equal(t, 2, r.calls)
+	require.Equal(t, 2, c.Len())
+
+	// errors are not cached
+	require.ErrorIs(t, resolve("did:example:4"), ErrNotFound)
+	require.ErrorIs(t, resolve("did:example:4"), ErrNotFound)
+	require.Equal(t, 4, r.calls)
+
+	// did:example:1 with default options is the least recently used item
+	// and is evicted
+	require.NoError(t, resolve("did:example:2"))
+	require.Equal(t, 5, r.calls)
+	require.Equal(t, 2, c.Len())
+	require.NoError(t, resolve("did:example:1"))
+	require.Equal(t, 6, r.calls)
+
+	// items expire after ttl
+	require.NoError(t, resolve("did:example:1"))
+	require.Equal(t, 6, r.calls)
+	now = now.Add(time.Minute)
+	require.NoError(t, resolve("did:example:1"))
+	require.Equal(t, 7, r.calls)
+
+	c.Invalidate(mustParseDID(t, "did:example:1"))
+	require.Equal(t, 1, c.Len())
+	require.NoError(t, resolve("did:example:1"))
+	require.Equal(t, 8, r.calls)
+}
+
+func TestResolverFunc(t *testing.T) {
+	var r Resolver = ResolverFunc(func(_ context.Context, did DID,
+		_ ResolutionOptions) (*DIDDocument, ResolutionMetadata,
+		DocumentMetadata, error) {
+
+		return &DIDDocument{ID: did}, ResolutionMetadata{},
+			DocumentMetadata{}, nil
+	})
+
+	did := mustParseDID(t, "did:example:123")
+	doc, _, _, err := r.Resolve(context.Background(), did,
+		ResolutionOptions{})
+	require.NoError(t, err)
+	require.Equal(t, did, doc.ID)
+}
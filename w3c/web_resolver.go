@@ -0,0 +1,153 @@
+package w3c
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// DIDMethodWeb is did:web method
+	// https://w3c-ccg.github.io/did-method-web/
+	DIDMethodWeb = "web"
+
+	// MediaTypeDIDJSON is the media type of JSON representation of DID
+	// document
+	MediaTypeDIDJSON = "application/did+json"
+
+	// webDIDMaxDocumentSize limits the size of fetched DID document
+	webDIDMaxDocumentSize = 1 << 20
+)
+
+// WebResolver resolves did:web DIDs by fetching DID documents over HTTPS.
+// DIDs with port must be parsed with ParseModeCore, as `%3A` is not allowed
+// by the default grammar.
+type WebResolver struct {
+	client *http.Client
+}
+
+// WebResolverOption configures WebResolver
+type WebResolverOption func(*WebResolver)
+
+// WithHTTPClient sets HTTP client used by WebResolver. http.DefaultClient
+// is used by default.
+func WithHTTPClient(client *http.Client) WebResolverOption {
+	return func(r *WebResolver) {
+		r.client = client
+	}
+}
+
+// NewWebResolver creates new WebResolver
+func NewWebResolver(opts ...WebResolverOption) *WebResolver {
+	r := &WebResolver{client: http.DefaultClient}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// WebDIDDocumentURL returns URL of DID document of did:web DID
+//
+//	did:web:example.com                -> https://example.com/.well-known/did.json
+//	did:web:example.com%3A8080         -> https://example.com:8080/.well-known/did.json
+//	did:web:example.com:user:alice     -> https://example.com/user/alice/did.json
+func WebDIDDocumentURL(did DID) (string, error) {
+	if did.Method != DIDMethodWeb {
+		return "", fmt.Errorf("%w: method is not web", ErrInvalidDID)
+	}
+	if did.IsURL() {
+		return "", fmt.Errorf("%w: DID URL can't be resolved", ErrInvalidDID)
+	}
+
+	idStrings := did.IDStrings
+	if len(idStrings) == 0 {
+		idStrings = strings.Split(did.ID, ":")
+	}
+
+	host, err := url.PathUnescape(idStrings[0])
+	if err != nil || host == "" || strings.ContainsAny(host, "/?#@") {
+		return "", fmt.Errorf("%w: invalid domain name", ErrInvalidDID)
+	}
+
+	path := "/.well-known"
+	if len(idStrings) > 1 {
+		for _, segment := range idStrings[1:] {
+			if segment == "" {
+				return "", fmt.Errorf("%w: empty path segment", ErrInvalidDID)
+			}
+		}
+		path = "/" + strings.Join(idStrings[1:], "/")
+	}
+
+	u, err := url.Parse("https://" + host + path + "/did.json")
+	if err != nil || u.Host != host {
+		return "", fmt.Errorf("%w: invalid domain name", ErrInvalidDID)
+	}
+	return u.String(), nil
+}
+
+// Resolve fetches and validates DID document of did:web DID. The ID of
+// fetched document must be equal to the DID. Returns ErrNotFound if the
+// server responds with 404 or 410 status.
+func (r *WebResolver) Resolve(ctx context.Context, did DID,
+	_ ResolutionOptions) (*DIDDocument, ResolutionMetadata, DocumentMetadata,
+	error) {
+
+	docURL, err := WebDIDDocumentURL(did)
+	if err != nil {
+		return failedResolution(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, docURL, nil)
+	if err != nil {
+		return failedResolution(err)
+	}
+	req.Header.Set("Accept", MediaTypeDIDJSON+", application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return failedResolution(
+			fmt.Errorf("can't fetch DID document: %w", err))
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound ||
+		resp.StatusCode == http.StatusGone:
+		return failedResolution(fmt.Errorf("%w: %v returned status %v",
+			ErrNotFound, docURL, resp.StatusCode))
+	case resp.StatusCode != http.StatusOK:
+		return failedResolution(fmt.Errorf(
+			"can't fetch DID document: %v returned status %v", docURL,
+			resp.StatusCode))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, webDIDMaxDocumentSize+1))
+	if err != nil {
+		return failedResolution(
+			fmt.Errorf("can't fetch DID document: %w", err))
+	}
+	if len(body) > webDIDMaxDocumentSize {
+		return failedResolution(fmt.Errorf(
+			"DID document is larger than %v bytes", webDIDMaxDocumentSize))
+	}
+
+	var doc DIDDocument
+	err = json.Unmarshal(body, &doc)
+	if err != nil {
+		return failedResolution(fmt.Errorf("invalid DID document: %w", err))
+	}
+
+	if !doc.ID.Equal(&did) {
+		return failedResolution(fmt.Errorf(
+			"invalid DID document: id %v does not match DID %v",
+			doc.ID.String(), did.String()))
+	}
+
+	return &doc, ResolutionMetadata{ContentType: MediaTypeDIDJSON},
+		DocumentMetadata{}, nil
+}
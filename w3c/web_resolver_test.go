@@ -0,0 +1,156 @@
+package w3c
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebDIDDocumentURL(t *testing.T) {
+	testCases := []struct {
+		did  string
+		want string
+	}{
+		{"did:web:w3c-ccg.github.io",
+			"https://w3c-ccg.github.io/.well-known/did.json"},
+		{"did:web:w3c-ccg.github.io:user:alice",
+			"https://w3c-ccg.github.io/user/alice/did.json"},
+		{"did:web:example.com%3A3000:user:alice",
+			"https://example.com:3000/user/alice/did.json"},
+	}
+	for _, tc := range testCases {
+		did, err := ParseDID(tc.did, WithParseMode(ParseModeCore))
+		require.NoError(t, err)
+		got, err := WebDIDDocumentURL(*did)
+		require.NoError(t, err)
+		require.Equal(t, tc.want, got)
+	}
+
+	invalid := []DID{
+		{Method: "example", ID: "example.com"},
+		{Method: DIDMethodWeb, ID: "example.com", Fragment: "key-1"},
+		{Method: DIDMethodWeb, ID: "example.com%2Fpath"},
+		{Method: DIDMethodWeb, ID: "user%40example.com"},
+		{Method: DIDMethodWeb, ID: "example.com::alice"},
+		{Method: DIDMethodWeb, ID: "example.com%3Aport"},
+	}
+	for _, did := range invalid {
+		_, err := WebDIDDocumentURL(did)
+		require.True(t, errors.Is(err, ErrInvalidDID), did.String())
+	}
+}
+
+// newTestWebServer starts TLS server that serves documents by path. `%s`
+// in documents is replaced by did:web DID of the server host.
+func newTestWebServer(t testing.TB,
+	docs map[string]string) (*httptest.Server, string) {
+
+	t.Helper()
+	var host string
+	srv := httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Accept") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			doc, ok := docs[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", MediaTypeDIDJSON)
+			_, _ = w.Write([]byte(strings.ReplaceAll(doc, "%s", host)))
+		}))
+	t.Cleanup(srv.Close)
+
+	host = "did:web:" + strings.ReplaceAll(
+		strings.TrimPrefix(srv.URL, "https://"), ":", "%3A")
+	return srv, host
+}
+
+func TestWebResolver_Resolve(t *testing.T) {
+	srv, didWeb := newTestWebServer(t, map[string]string{
+		"/.well-known/did.json": `{
+  "@context": "https://www.w3.org/ns/did/v1",
+  "id": "%s",
+  "verificationMethod": [{
+    "id": "%s#key-1",
+    "type": "JsonWebKey2020",
+    "controller": "%s",
+    "publicKeyJwk": {"kty": "OKP", "crv": "Ed25519",
+      "x": "VCpo2LMLhn6iWku8MKvSLg2ZAoC-nlOyPVQaO3FxVeQ"}
+  }],
+  "authentication": ["#key-1"]
+}`,
+		"/user/alice/did.json": `{"id": "%s:user:alice"}`,
+		"/user/bob/did.json":   `{"id": "%s:user:alice"}`,
+		"/user/eve/did.json":   `{"id": `,
+	})
+	r := NewWebResolver(WithHTTPClient(srv.Client()))
+
+	did, err := ParseDID(didWeb, WithParseMode(ParseModeCore))
+	require.NoError(t, err)
+	doc, meta, _, err := r.Resolve(context.Background(), *did,
+		ResolutionOptions{})
+	require.NoError(t, err)
+	require.Equal(t, MediaTypeDIDJSON, meta.ContentType)
+	require.Equal(t, didWeb, doc.ID.String())
+	require.Equal(t, didWeb+"#key-1", doc.Authentication[0].Reference.String())
+
+	// dereferencing works through the resolver
+	didURL, err := ParseDID(didWeb+"#key-1", WithParseMode(ParseModeCore))
+	require.NoError(t, err)
+	res, err := Dereference(context.Background(), r, *didURL)
+	require.NoError(t, err)
+	require.Equal(t, "JsonWebKey2020", res.VerificationMethod.Type)
+
+	did, err = ParseDID(didWeb+":user:alice", WithParseMode(ParseModeCore))
+	require.NoError(t, err)
+	doc, _, _, err = r.Resolve(context.Background(), *did,
+		ResolutionOptions{})
+	require.NoError(t, err)
+	require.Equal(t, didWeb+":user:alice", doc.ID.String())
+
+	testCases := []struct {
+		name string
+		path string
+		code string
+	}{
+		{"not found", ":user:carol", ErrNotFound.Error()},
+		{"id mismatch", ":user:bob", ResolutionErrorInternal},
+		{"invalid document", ":user:eve", ResolutionErrorInternal},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			did, err := ParseDID(didWeb+tc.path,
+				WithParseMode(ParseModeCore))
+			require.NoError(t, err)
+			doc, meta, _, err := r.Resolve(context.Background(), *did,
+				ResolutionOptions{})
+			require.Error(t, err)
+			require.Nil(t, doc)
+			require.Equal(t, tc.code, meta.Error)
+			require.Equal(t, tc.code, ResolutionErrorCode(err))
+		})
+	}
+}
+
+func TestWebResolver_UntrustedCertificate(t *testing.T) {
+	_, didWeb := newTestWebServer(t, map[string]string{
+		"/.well-known/did.json": `{"id": "%s"}`,
+	})
+
+	// default client does not trust test server certificate
+	r := NewWebResolver()
+	did, err := ParseDID(didWeb, WithParseMode(ParseModeCore))
+	require.NoError(t, err)
+	_, meta, _, err := r.Resolve(context.Background(), *did,
+		ResolutionOptions{})
+	require.Error(t, err)
+	require.Equal(t, ResolutionErrorInternal, meta.Error)
+}